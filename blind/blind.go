@@ -0,0 +1,222 @@
+// Package blind 实现盲 Schnorr 签名: 签名者在看不到消息的情况下签名，结果是 schnorr.Verify 可以验证的普通签名
+//
+// 协议:
+// 签名者: 随机数 k，R = k*G，发送 R (Signer.Commit)
+// 用户: 随机选 α, β，R' = R + α*G + β*P，要求 R'y 是二次剩余(否则重新选)，e = getE(R'x, P, m)，发送 c = e + β (Blind)
+// 签名者: s = k + c*d，发送 s (Signer.Respond)
+// 用户: 检查 s*G = R + c*P，s' = s + α，签名为 (R'x, s') (User.Unblind)
+// 验证: s'*G - e*P = k*G + (e+β)*P + α*G - e*P = R + α*G + β*P = R'
+//
+// 安全性:
+// 签名者看到的 (R, c, s) 和最终签名 (R'x, s') 在 α, β 均匀随机时相互独立，签名者无法关联(完美盲化)
+// 不可伪造性依赖同时打开的会话数量。签名者同时打开 ℓ 个会话时，
+// 攻击者可以用 ROS 问题的解在 ℓ 个会话之后得到 ℓ+1 个有效签名:
+// ℓ > 256 时存在多项式时间攻击 (Benhamouda 等, 2020)，更小的 ℓ 也有 Wagner 广义生日攻击，安全级别随 ℓ 下降
+// 只有 ℓ = 1 (会话严格依次进行) 时在代数群模型和随机预言机模型下归约到 OMDL 问题，达到约128位安全
+// 因此 Signer 限制同时打开的会话数量，默认只允许一个
+package blind
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"sync"
+)
+
+// DefaultMaxSessions 默认同时打开的会话数量，见包文档中的安全性说明
+const DefaultMaxSessions = 1
+
+// Signer 签名者，可以在多个协程中使用
+type Signer struct {
+	mu          sync.Mutex
+	d           [32]byte
+	publicKey   [33]byte
+	maxSessions int
+	nextID      uint64
+	sessions    map[uint64]*[32]byte
+}
+
+// NewSigner 创建签名者，maxSessions 是同时打开的会话数量上限，小于1时使用 DefaultMaxSessions
+// maxSessions 越大，允许的并发越高，但安全级别越低，不应超过几个
+func NewSigner(d [32]byte, maxSessions int) (*Signer, error) {
+	x, err := schnorr.NewScalar(d)
+	if err != nil || x.IsZero() {
+		return nil, errors.New("invalid private key")
+	}
+	if maxSessions < 1 {
+		maxSessions = DefaultMaxSessions
+	}
+	s := &Signer{
+		d:           d,
+		maxSessions: maxSessions,
+		sessions:    make(map[uint64]*[32]byte),
+	}
+	s.publicKey = new(schnorr.Point).BaseMul(x).Bytes()
+	return s, nil
+}
+
+// PublicKey 签名者的公钥
+func (s *Signer) PublicKey() [33]byte {
+	return s.publicKey
+}
+
+// Commit 第一轮，打开一个会话，返回会话编号和 R
+// 打开的会话达到上限时返回错误，需要等其他会话结束(Respond 或 Abort)
+func (s *Signer) Commit() (id uint64, R [33]byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sessions) >= s.maxSessions {
+		return 0, R, errors.New("too many open sessions")
+	}
+	id = s.nextID
+	var aux [32]byte
+	if _, err = rand.Read(aux[:]); err != nil {
+		return 0, R, err
+	}
+	var idBytes [8]byte
+	binary.BigEndian.PutUint64(idBytes[:], id)
+	k0, R, err := schnorr.GenNonce(s.d, idBytes[:], aux[:])
+	if err != nil {
+		return 0, R, err
+	}
+	s.nextID++
+	s.sessions[id] = &k0
+	return id, R, nil
+}
+
+// Respond 第三轮，对用户盲化后的挑战值 c 签名 s = k + c*d，会话随之关闭
+func (s *Signer) Respond(id uint64, c [32]byte) (sig [32]byte, err error) {
+	s.mu.Lock()
+	k0, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	if !ok {
+		return sig, errors.New("session not found")
+	}
+	defer func() { *k0 = [32]byte{} }()
+
+	// k 和 d 是秘密的，用固定时间的 Scalar 计算
+	v, err := schnorr.NewScalar(c)
+	if err != nil {
+		return sig, errors.New("invalid challenge")
+	}
+	k, err := schnorr.NewScalar(*k0)
+	if err != nil {
+		return sig, err
+	}
+	d, err := schnorr.NewScalar(s.d)
+	if err != nil {
+		return sig, err
+	}
+	v.Mul(v, d).Add(v, k)
+	return v.Bytes(), nil
+}
+
+// Abort 放弃一个会话
+func (s *Signer) Abort(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k0, ok := s.sessions[id]; ok {
+		*k0 = [32]byte{}
+		delete(s.sessions, id)
+	}
+}
+
+// User 用户一次盲签名的状态
+type User struct {
+	publicKey [33]byte
+	message   []byte
+	R         [33]byte
+	c         [32]byte
+	alpha     *big.Int
+	rx        [32]byte
+}
+
+// Blind 第二轮，用签名者的 R 盲化消息，返回用户状态和需要发给签名者的 c
+func Blind(publicKey [33]byte, R [33]byte, message []byte) (*User, [32]byte, error) {
+	var c [32]byte
+	N := schnorr.Curve.N
+	Px, Py := schnorr.Unmarshal(schnorr.Curve, publicKey[:])
+	if Px == nil {
+		return nil, c, errors.New("invalid public key")
+	}
+	Rx, Ry := schnorr.Unmarshal(schnorr.Curve, R[:])
+	if Rx == nil {
+		return nil, c, errors.New("invalid nonce")
+	}
+	for {
+		alpha, err := randScalar()
+		if err != nil {
+			return nil, c, err
+		}
+		beta, err := randScalar()
+		if err != nil {
+			return nil, c, err
+		}
+		// R' = R + α*G + β*P
+		aGx, aGy := schnorr.Curve.ScalarBaseMult(schnorr.IntToByte(alpha))
+		bPx, bPy := schnorr.Curve.ScalarMult(Px, Py, schnorr.IntToByte(beta))
+		RBx, RBy := schnorr.Curve.Add(Rx, Ry, aGx, aGy)
+		RBx, RBy = schnorr.Curve.Add(RBx, RBy, bPx, bPy)
+		if RBx.Sign() == 0 && RBy.Sign() == 0 {
+			continue
+		}
+		// R'y 必须是二次剩余，否则最终签名无法通过 Verify
+		if big.Jacobi(RBy, schnorr.Curve.P) != 1 {
+			continue
+		}
+		u := &User{
+			publicKey: publicKey,
+			message:   append([]byte{}, message...),
+			R:         R,
+			alpha:     alpha,
+		}
+		copy(u.rx[:], schnorr.IntToByte(RBx))
+		e := schnorr.Challenge(u.rx, publicKey, message)
+		e.Add(e, beta)
+		e.Mod(e, N)
+		copy(u.c[:], schnorr.IntToByte(e))
+		return u, u.c, nil
+	}
+}
+
+// Unblind 第四轮，检查签名者的回应 s*G = R + c*P，得到最终签名 (R'x, s + α)
+func (u *User) Unblind(s [32]byte) (signature [64]byte, err error) {
+	N := schnorr.Curve.N
+	sInt := new(big.Int).SetBytes(s[:])
+	if sInt.Cmp(N) >= 0 {
+		return signature, errors.New("invalid response")
+	}
+	Px, Py := schnorr.Unmarshal(schnorr.Curve, u.publicKey[:])
+	Rx, Ry := schnorr.Unmarshal(schnorr.Curve, u.R[:])
+	cPx, cPy := schnorr.Curve.ScalarMult(Px, Py, u.c[:])
+	Rx, Ry = schnorr.Curve.Add(Rx, Ry, cPx, cPy)
+	sGx, sGy := schnorr.Curve.ScalarBaseMult(s[:])
+	if sGx.Cmp(Rx) != 0 || sGy.Cmp(Ry) != 0 {
+		return signature, errors.New("invalid response")
+	}
+
+	sInt.Add(sInt, u.alpha)
+	sInt.Mod(sInt, N)
+	copy(signature[:32], u.rx[:])
+	copy(signature[32:], schnorr.IntToByte(sInt))
+	if ret, err := schnorr.Verify(u.publicKey, u.message, signature); err != nil || !ret {
+		return [64]byte{}, errors.New("signature verification failed")
+	}
+	return signature, nil
+}
+
+func randScalar() (*big.Int, error) {
+	for {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(b[:])
+		if k.Sign() != 0 && k.Cmp(schnorr.Curve.N) < 0 {
+			return k, nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package blind
+
+import (
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func TestBlindSign(t *testing.T) {
+	d, P := schnorr.GenKey()
+	signer, err := NewSigner(d, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.PublicKey() != P {
+		t.Fatal("public key mismatch")
+	}
+	for i := 0; i < 5; i++ {
+		message := []byte("token")
+		id, R, err := signer.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, c, err := Blind(P, R, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := signer.Respond(id, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := user.Unblind(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret, err := schnorr.Verify(P, message, signature); err != nil || !ret {
+			t.Fatal("signature verification failed")
+		}
+		// 签名者看到的 R 和最终签名的 r 不同
+		var r [32]byte
+		copy(r[:], signature[:32])
+		if string(r[:]) == string(R[1:]) {
+			t.Fatal("signature is not blinded")
+		}
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	d, _ := schnorr.GenKey()
+	signer, err := NewSigner(d, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _, err := signer.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := signer.Commit(); err == nil {
+		t.Fatal("second concurrent session should be rejected")
+	}
+	signer.Abort(id)
+	id, _, err = signer.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c [32]byte
+	c[31] = 1
+	if _, err := signer.Respond(id, c); err != nil {
+		t.Fatal(err)
+	}
+	// 会话只能回应一次
+	if _, err := signer.Respond(id, c); err == nil {
+		t.Fatal("session should be closed after respond")
+	}
+}
+
+func TestBadResponse(t *testing.T) {
+	d, P := schnorr.GenKey()
+	signer, err := NewSigner(d, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, R, err := signer.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, c, err := Blind(P, R, []byte("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := signer.Respond(id, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s[31] ^= 1
+	if _, err := user.Unblind(s); err == nil {
+		t.Fatal("bad response should be rejected")
+	}
+}
@@ -0,0 +1,392 @@
+// Package dkg 实现没有可信分发者的分布式密钥生成 (Pedersen DKG, 每个参与者做一次 Feldman VSS)
+// 生成的 frost.KeyShare 可以直接用于 frost 门限签名，群公钥可以用 schnorr.Verify 验证签名
+//
+// 流程(所有广播消息必须通过可靠广播信道发送，保证每个人收到的内容相同):
+// 第一轮: 每个参与者生成随机多项式 fi，广播 Feldman 承诺和 fi(0) 的知识证明 Round1()
+// 知识证明的挑战值包含会话上下文和自己的编号，不能在其他会话中重放，也不能被其他参与者当作自己的证明
+// 第二轮: 检查所有人的第一轮消息，知识证明不通过的直接取消资格；给每个参与者私下发送 fi(j) Round2()
+// 投诉:   收到的分片和承诺不一致时广播投诉 ReceiveShares()，被投诉者公开该分片 Respond()，
+// 所有人检查公开的分片 ResolveComplaint()，不一致或者不回应的取消资格
+// 结束:   Finalize() 由所有合格参与者的分片相加得到自己的 KeyShare
+package dkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/frost"
+	"schnorr/schnorr-go/schnorr"
+	"sort"
+)
+
+const pokTag = "schnorr-go/dkg-pok"
+
+// Round1Message 第一轮广播，承诺和对 fi(0) 的知识证明 Rx||s
+type Round1Message struct {
+	From       uint32
+	Commitment frost.Commitment
+	Proof      [64]byte
+}
+
+// Round2Message 第二轮私下发送给 To 的分片 fFrom(To)
+type Round2Message struct {
+	From  uint32
+	To    uint32
+	Share [32]byte
+}
+
+// Complaint Accuser 投诉 Accused 发送的分片和承诺不一致(或者没有发送)
+type Complaint struct {
+	Accuser uint32
+	Accused uint32
+}
+
+// Participant DKG 中一个参与者的状态，只能在一个协程中使用
+type Participant struct {
+	id           uint32
+	threshold    int
+	context      []byte
+	ids          []uint32
+	poly         []*schnorr.Scalar
+	commitments  map[uint32]frost.Commitment
+	shares       map[uint32][32]byte
+	disqualified map[uint32]bool
+}
+
+// NewParticipant ids 是所有参与者的编号(包括自己)，编号从1开始且不能重复
+// context 是所有参与者约定的会话上下文(例如会话编号)，每次 DKG 都应该不同
+func NewParticipant(id uint32, threshold int, ids []uint32, context []byte) (*Participant, error) {
+	if threshold < 1 || threshold > len(ids) {
+		return nil, errors.New("invalid threshold")
+	}
+	sorted := append([]uint32{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	found := false
+	for i, j := range sorted {
+		if j == 0 {
+			return nil, errors.New("invalid identifier")
+		}
+		if i > 0 && sorted[i-1] == j {
+			return nil, errors.New("duplicate identifier")
+		}
+		if j == id {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.New("identifier is not in participants")
+	}
+	return &Participant{
+		id:           id,
+		threshold:    threshold,
+		context:      append([]byte{}, context...),
+		ids:          sorted,
+		commitments:  make(map[uint32]frost.Commitment),
+		shares:       make(map[uint32][32]byte),
+		disqualified: make(map[uint32]bool),
+	}, nil
+}
+
+// ID 自己的编号
+func (p *Participant) ID() uint32 {
+	return p.id
+}
+
+// Round1 生成随机多项式，返回需要广播的承诺和知识证明
+func (p *Participant) Round1() (*Round1Message, error) {
+	if p.poly != nil {
+		return nil, errors.New("round1 already done")
+	}
+	// 系数是秘密的，只用固定时间的 Scalar 和 Point.BaseMul 计算
+	var poly []*schnorr.Scalar
+	for i := 0; i < p.threshold; i++ {
+		a, err := schnorr.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		poly = append(poly, a)
+	}
+	msg := &Round1Message{From: p.id}
+	for _, a := range poly {
+		msg.Commitment = append(msg.Commitment, new(schnorr.Point).BaseMul(a).Bytes())
+	}
+	proof, err := p.prove(poly[0], msg.Commitment[0])
+	if err != nil {
+		return nil, err
+	}
+	p.poly = poly
+	msg.Proof = proof
+	p.commitments[p.id] = msg.Commitment
+	p.shares[p.id] = p.evaluate(p.id)
+	return msg, nil
+}
+
+// Round2 检查所有人的第一轮消息，返回发给其他每个参与者的分片
+// 承诺格式错误或知识证明不通过(包括其他会话或其他参与者的证明)的参与者被取消资格，不给他发送分片
+func (p *Participant) Round2(msgs []*Round1Message) ([]*Round2Message, error) {
+	if p.poly == nil {
+		return nil, errors.New("round1 is not done")
+	}
+	for _, msg := range msgs {
+		if msg.From == p.id || !p.isParticipant(msg.From) {
+			continue
+		}
+		if _, ok := p.commitments[msg.From]; ok {
+			return nil, errors.New("duplicate round1 message")
+		}
+		if len(msg.Commitment) != p.threshold {
+			p.disqualified[msg.From] = true
+			continue
+		}
+		if _, _, err := msg.Commitment.Evaluate(p.id); err != nil {
+			p.disqualified[msg.From] = true
+			continue
+		}
+		if !p.verifyProof(msg.From, msg.Commitment[0], msg.Proof) {
+			p.disqualified[msg.From] = true
+			continue
+		}
+		p.commitments[msg.From] = msg.Commitment
+	}
+	var out []*Round2Message
+	for _, id := range p.ids {
+		if _, ok := p.commitments[id]; !ok {
+			// 没有发送第一轮消息
+			p.disqualified[id] = true
+			continue
+		}
+		if id == p.id || p.disqualified[id] {
+			continue
+		}
+		out = append(out, &Round2Message{From: p.id, To: id, Share: p.evaluate(id)})
+	}
+	return out, nil
+}
+
+// ReceiveShares 检查收到的分片，返回需要广播的投诉
+func (p *Participant) ReceiveShares(msgs []*Round2Message) []Complaint {
+	received := make(map[uint32]bool)
+	var complaints []Complaint
+	for _, msg := range msgs {
+		if msg.To != p.id || p.disqualified[msg.From] || received[msg.From] {
+			continue
+		}
+		commitment, ok := p.commitments[msg.From]
+		if !ok {
+			continue
+		}
+		received[msg.From] = true
+		if err := commitment.VerifyShare(p.id, msg.Share); err != nil {
+			complaints = append(complaints, Complaint{Accuser: p.id, Accused: msg.From})
+			continue
+		}
+		p.shares[msg.From] = msg.Share
+	}
+	for _, id := range p.ids {
+		if id != p.id && !p.disqualified[id] && !received[id] {
+			complaints = append(complaints, Complaint{Accuser: p.id, Accused: id})
+		}
+	}
+	return complaints
+}
+
+// Respond 被投诉时公开发给投诉者的分片
+func (p *Participant) Respond(c Complaint) (*Round2Message, error) {
+	if c.Accused != p.id {
+		return nil, errors.New("complaint is not against this participant")
+	}
+	if !p.isParticipant(c.Accuser) {
+		return nil, errors.New("invalid complaint")
+	}
+	if p.disqualified[c.Accuser] {
+		return nil, errors.New("accuser is disqualified")
+	}
+	return &Round2Message{From: p.id, To: c.Accuser, Share: p.evaluate(c.Accuser)}, nil
+}
+
+// ResolveComplaint 所有参与者用被投诉者公开的分片处理投诉，revealed 为 nil 表示没有回应
+// 公开的分片和承诺不一致或者没有回应时取消被投诉者的资格；否则投诉者使用公开的分片
+// 被取消资格的参与者的投诉不需要处理
+func (p *Participant) ResolveComplaint(c Complaint, revealed *Round2Message) {
+	if p.disqualified[c.Accused] || p.disqualified[c.Accuser] {
+		return
+	}
+	commitment, ok := p.commitments[c.Accused]
+	if !ok {
+		p.disqualified[c.Accused] = true
+		return
+	}
+	if revealed == nil || revealed.From != c.Accused || revealed.To != c.Accuser {
+		p.disqualified[c.Accused] = true
+		return
+	}
+	if err := commitment.VerifyShare(c.Accuser, revealed.Share); err != nil {
+		p.disqualified[c.Accused] = true
+		return
+	}
+	if c.Accuser == p.id {
+		p.shares[c.Accused] = revealed.Share
+	}
+}
+
+// Disqualified 被取消资格的参与者
+func (p *Participant) Disqualified() []uint32 {
+	var ret []uint32
+	for _, id := range p.ids {
+		if p.disqualified[id] {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+// Finalize 所有投诉处理完之后，计算自己的 KeyShare 和所有人的公开信息
+// 私钥分片 si = Σ fj(i)，群公钥 P = Σ Cj[0]，j 为所有合格的参与者
+func (p *Participant) Finalize() (*frost.KeyShare, *frost.PublicKeyPackage, error) {
+	if p.disqualified[p.id] {
+		return nil, nil, errors.New("participant is disqualified")
+	}
+	var qualified []uint32
+	for _, id := range p.ids {
+		if !p.disqualified[id] {
+			qualified = append(qualified, id)
+		}
+	}
+	if len(qualified) < p.threshold {
+		return nil, nil, errors.New("not enough qualified participants")
+	}
+
+	secret := new(schnorr.Scalar)
+	Px, Py := schnorr.Zero, schnorr.Zero
+	for _, j := range qualified {
+		share, ok := p.shares[j]
+		if !ok {
+			return nil, nil, errors.New("share is not received")
+		}
+		s, err := schnorr.NewScalar(share)
+		if err != nil {
+			return nil, nil, err
+		}
+		secret.Add(secret, s)
+		Ax, Ay := schnorr.Unmarshal(schnorr.Curve, p.commitments[j][0][:])
+		Px, Py = schnorr.Curve.Add(Px, Py, Ax, Ay)
+	}
+	if Px.Sign() == 0 && Py.Sign() == 0 {
+		return nil, nil, errors.New("group key is infinity")
+	}
+	var groupKey [33]byte
+	copy(groupKey[:], schnorr.Marshal(schnorr.Curve, Px, Py))
+
+	keyShare, err := frost.NewKeyShare(p.id, p.threshold, secret, groupKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub := &frost.PublicKeyPackage{
+		Threshold: p.threshold,
+		GroupKey:  groupKey,
+		Shares:    make(map[uint32][33]byte),
+	}
+	// 合格参与者 i 的公钥 Yi = Σ fj(i)*G
+	for _, i := range qualified {
+		Yx, Yy := schnorr.Zero, schnorr.Zero
+		for _, j := range qualified {
+			x, y, err := p.commitments[j].Evaluate(i)
+			if err != nil {
+				return nil, nil, err
+			}
+			Yx, Yy = schnorr.Curve.Add(Yx, Yy, x, y)
+		}
+		var Y [33]byte
+		copy(Y[:], schnorr.Marshal(schnorr.Curve, Yx, Yy))
+		pub.Shares[i] = Y
+	}
+	if pub.Shares[p.id] != keyShare.Public {
+		return nil, nil, errors.New("public share does not match secret")
+	}
+	p.clear()
+	return keyShare, pub, nil
+}
+
+// evaluate 计算 f(id)
+func (p *Participant) evaluate(id uint32) (share [32]byte) {
+	x := new(schnorr.Scalar).SetBig(new(big.Int).SetUint64(uint64(id)))
+	ret := new(schnorr.Scalar)
+	for i := len(p.poly) - 1; i >= 0; i-- {
+		ret.Mul(ret, x)
+		ret.Add(ret, p.poly[i])
+	}
+	return ret.Bytes()
+}
+
+// prove 证明知道 A0 = a0*G，s = k + e*a0，R = k*G 的 y 坐标为二次剩余(否则 k 取反)
+// e = TaggedHash("schnorr-go/dkg-pok", len(context)||context||id||A0||Rx)
+func (p *Participant) prove(a0 *schnorr.Scalar, A0 [33]byte) (proof [64]byte, err error) {
+	k, err := schnorr.RandomScalar()
+	if err != nil {
+		return proof, err
+	}
+	Rx, Ry := new(schnorr.Point).BaseMul(k).Coordinates()
+	if big.Jacobi(Ry, schnorr.Curve.P) != 1 {
+		k.Neg(k)
+	}
+	var rx [32]byte
+	copy(rx[:], schnorr.IntToByte(Rx))
+	s := p.challenge(p.id, A0, rx)
+	s.Mul(s, a0).Add(s, k)
+	sb := s.Bytes()
+	copy(proof[:32], rx[:])
+	copy(proof[32:], sb[:])
+	return proof, nil
+}
+
+// verifyProof 检查 from 对 A0 的知识证明: R = s*G - e*A0，Rx 一致且 Ry 为二次剩余
+func (p *Participant) verifyProof(from uint32, A0 [33]byte, proof [64]byte) bool {
+	P, err := new(schnorr.Point).SetBytes(A0[:])
+	if err != nil {
+		return false
+	}
+	var rx, sb [32]byte
+	copy(rx[:], proof[:32])
+	copy(sb[:], proof[32:])
+	s, err := schnorr.NewScalar(sb)
+	if err != nil {
+		return false
+	}
+	eP := new(schnorr.Point).Mul(p.challenge(from, A0, rx), P)
+	R := new(schnorr.Point).BaseMul(s)
+	R.Sub(R, eP)
+	if R.IsInfinity() {
+		return false
+	}
+	Rx, Ry := R.Coordinates()
+	return Rx.Cmp(new(big.Int).SetBytes(rx[:])) == 0 && big.Jacobi(Ry, schnorr.Curve.P) == 1
+}
+
+func (p *Participant) challenge(id uint32, A0 [33]byte, rx [32]byte) *schnorr.Scalar {
+	var contextLen [8]byte
+	var idBytes [4]byte
+	binary.BigEndian.PutUint64(contextLen[:], uint64(len(p.context)))
+	binary.BigEndian.PutUint32(idBytes[:], id)
+	h := schnorr.TaggedHash(pokTag, contextLen[:], p.context, idBytes[:], A0[:], rx[:])
+	return new(schnorr.Scalar).SetBig(new(big.Int).SetBytes(h[:]))
+}
+
+func (p *Participant) isParticipant(id uint32) bool {
+	for _, j := range p.ids {
+		if j == id {
+			return true
+		}
+	}
+	return false
+}
+
+// clear 结束之后清除多项式和收到的分片
+func (p *Participant) clear() {
+	for _, a := range p.poly {
+		a.Set(new(schnorr.Scalar))
+	}
+	p.poly = nil
+	for id := range p.shares {
+		delete(p.shares, id)
+	}
+}
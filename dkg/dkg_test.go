@@ -0,0 +1,299 @@
+package dkg
+
+import (
+	"schnorr/schnorr-go/frost"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+// network 内存中的多方运行环境，可以修改消息模拟恶意参与者
+type network struct {
+	t            *testing.T
+	participants []*Participant
+	// round1 修改第一轮广播
+	round1 func(msg *Round1Message)
+	// round2 修改第二轮发送的分片
+	round2 func(msg *Round2Message)
+	// respond 修改对投诉的回应，返回 nil 表示不回应
+	respond func(c Complaint, msg *Round2Message) *Round2Message
+}
+
+func newNetwork(t *testing.T, threshold, n int) *network {
+	return newNetworkContext(t, threshold, n, []byte("test session"))
+}
+
+func newNetworkContext(t *testing.T, threshold, n int, context []byte) *network {
+	var ids []uint32
+	for i := 1; i <= n; i++ {
+		ids = append(ids, uint32(i))
+	}
+	net := &network{t: t}
+	for _, id := range ids {
+		p, err := NewParticipant(id, threshold, ids, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.participants = append(net.participants, p)
+	}
+	return net
+}
+
+// run 运行整个协议，返回每个参与者的结果，被取消资格的参与者结果为 nil
+func (net *network) run() ([]*frost.KeyShare, []*frost.PublicKeyPackage) {
+	t := net.t
+	var round1 []*Round1Message
+	for _, p := range net.participants {
+		msg, err := p.Round1()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if net.round1 != nil {
+			net.round1(msg)
+		}
+		round1 = append(round1, msg)
+	}
+
+	var round2 []*Round2Message
+	for _, p := range net.participants {
+		msgs, err := p.Round2(round1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range msgs {
+			if net.round2 != nil {
+				net.round2(msg)
+			}
+		}
+		round2 = append(round2, msgs...)
+	}
+
+	var complaints []Complaint
+	for _, p := range net.participants {
+		complaints = append(complaints, p.ReceiveShares(round2)...)
+	}
+	for _, c := range complaints {
+		revealed, err := net.participants[c.Accused-1].Respond(c)
+		if err != nil {
+			// 投诉者已经被取消资格
+			continue
+		}
+		if net.respond != nil {
+			revealed = net.respond(c, revealed)
+		}
+		for _, p := range net.participants {
+			p.ResolveComplaint(c, revealed)
+		}
+	}
+
+	var shares []*frost.KeyShare
+	var pubs []*frost.PublicKeyPackage
+	for _, p := range net.participants {
+		share, pub, err := p.Finalize()
+		if err != nil {
+			shares = append(shares, nil)
+			pubs = append(pubs, nil)
+			continue
+		}
+		shares = append(shares, share)
+		pubs = append(pubs, pub)
+	}
+	return shares, pubs
+}
+
+// checkSign 检查所有合格参与者得到相同的群公钥，并用其中 threshold 个签名
+func checkSign(t *testing.T, shares []*frost.KeyShare, pubs []*frost.PublicKeyPackage, expectDisqualified []uint32) {
+	var signers []*frost.KeyShare
+	var pub *frost.PublicKeyPackage
+	for i, share := range shares {
+		id := uint32(i + 1)
+		bad := false
+		for _, j := range expectDisqualified {
+			bad = bad || j == id
+		}
+		if bad {
+			continue
+		}
+		if share == nil {
+			t.Fatal("honest participant failed", id)
+		}
+		if pub == nil {
+			pub = pubs[i]
+		} else if pubs[i].GroupKey != pub.GroupKey {
+			t.Fatal("group key mismatch")
+		}
+		signers = append(signers, share)
+	}
+	for _, j := range expectDisqualified {
+		if _, ok := pub.Shares[j]; ok {
+			t.Fatal("disqualified participant is in public key package", j)
+		}
+	}
+	signers = signers[:pub.Threshold]
+
+	message := []byte("test msg")
+	pkg := &frost.SigningPackage{Message: message}
+	var nonces []*frost.SigningNonces
+	for _, share := range signers {
+		n, c, err := frost.Commit(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, n)
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+	var sigShares []frost.SignatureShare
+	for i, share := range signers {
+		sigShare, err := frost.Sign(pkg, nonces[i], share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigShares = append(sigShares, sigShare)
+	}
+	signature, err := frost.Aggregate(pkg, pub, sigShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := schnorr.Verify(pub.GroupKey, message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+}
+
+func TestDKG(t *testing.T) {
+	net := newNetwork(t, 3, 5)
+	shares, pubs := net.run()
+	for _, p := range net.participants {
+		if len(p.Disqualified()) != 0 {
+			t.Fatal("no participant should be disqualified")
+		}
+	}
+	checkSign(t, shares, pubs, nil)
+}
+
+func TestBadProof(t *testing.T) {
+	net := newNetwork(t, 3, 5)
+	net.round1 = func(msg *Round1Message) {
+		if msg.From == 2 {
+			msg.Proof[40] ^= 1
+		}
+	}
+	shares, pubs := net.run()
+	checkSign(t, shares, pubs, []uint32{2})
+}
+
+// checkRound1 运行第一轮，检查参与者1在 Round2 中就取消了 expect 的资格
+func (net *network) checkRound1(expect uint32) {
+	t := net.t
+	var msgs []*Round1Message
+	for _, p := range net.participants {
+		msg, err := p.Round1()
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.round1(msg)
+		msgs = append(msgs, msg)
+	}
+	if _, err := net.participants[0].Round2(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if d := net.participants[0].Disqualified(); len(d) != 1 || d[0] != expect {
+		t.Fatal("expected participant to be disqualified", expect, d)
+	}
+}
+
+// 其他会话中的第一轮消息不能重放
+func TestReplayedProof(t *testing.T) {
+	old := newNetworkContext(t, 3, 5, []byte("old session"))
+	replayed, err := old.participants[1].Round1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := newNetwork(t, 3, 5)
+	net.round1 = func(msg *Round1Message) {
+		if msg.From == 2 {
+			*msg = *replayed
+		}
+	}
+	net.checkRound1(2)
+}
+
+// 参与者 3 把 2 的承诺和证明当作自己的发送
+func TestReattributedProof(t *testing.T) {
+	net := newNetwork(t, 3, 5)
+	var copied Round1Message
+	net.round1 = func(msg *Round1Message) {
+		switch msg.From {
+		case 2:
+			copied = *msg
+		case 3:
+			msg.Commitment, msg.Proof = copied.Commitment, copied.Proof
+		}
+	}
+	net.checkRound1(3)
+}
+
+func TestBadShareRevealedBad(t *testing.T) {
+	// 参与者 4 给 1 发了错误的分片，被投诉后公开的仍然是错误的分片
+	net := newNetwork(t, 3, 5)
+	net.round2 = func(msg *Round2Message) {
+		if msg.From == 4 && msg.To == 1 {
+			msg.Share[31] ^= 1
+		}
+	}
+	net.respond = func(c Complaint, msg *Round2Message) *Round2Message {
+		if c.Accused == 4 {
+			msg.Share[31] ^= 1
+		}
+		return msg
+	}
+	shares, pubs := net.run()
+	checkSign(t, shares, pubs, []uint32{4})
+}
+
+func TestBadShareNoResponse(t *testing.T) {
+	net := newNetwork(t, 3, 5)
+	net.round2 = func(msg *Round2Message) {
+		if msg.From == 5 && msg.To == 3 {
+			msg.To = 2
+		}
+	}
+	net.respond = func(c Complaint, msg *Round2Message) *Round2Message {
+		if c.Accused == 5 {
+			return nil
+		}
+		return msg
+	}
+	shares, pubs := net.run()
+	checkSign(t, shares, pubs, []uint32{5})
+}
+
+func TestBadShareRevealedGood(t *testing.T) {
+	// 参与者 3 发错了分片，但公开了正确的分片，不取消资格
+	net := newNetwork(t, 2, 4)
+	net.round2 = func(msg *Round2Message) {
+		if msg.From == 3 && msg.To == 2 {
+			msg.Share[0] ^= 1
+		}
+	}
+	shares, pubs := net.run()
+	for _, p := range net.participants {
+		if len(p.Disqualified()) != 0 {
+			t.Fatal("no participant should be disqualified")
+		}
+	}
+	checkSign(t, shares, pubs, nil)
+}
+
+func TestTooManyBadDealers(t *testing.T) {
+	net := newNetwork(t, 3, 4)
+	net.round1 = func(msg *Round1Message) {
+		if msg.From <= 2 {
+			msg.Proof[40] ^= 1
+		}
+	}
+	shares, _ := net.run()
+	for _, share := range shares[2:] {
+		if share != nil {
+			t.Fatal("finalize should fail without enough qualified participants")
+		}
+	}
+}
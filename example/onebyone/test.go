@@ -16,24 +16,36 @@ func main()  {
 		publicKeys = append(publicKeys, publicKey)
 	}
 
-	// 开始每个用户依次签名，注意每个用户可以拿到所有人的私钥，但是只持有自己的私钥
+	// 每个用户生成自己的随机数，k0自己保存，R发给其他人
 	message := []byte("test msg")
+	var k0s [][32]byte
+	var publicNonces [][33]byte
+	for _, privateKey := range privateKeys {
+		k0, R, err := multisign.GenNonce(privateKey, message)
+		if err != nil {
+			panic(err)
+		}
+		k0s = append(k0s, k0)
+		publicNonces = append(publicNonces, R)
+	}
+
+	// 开始每个用户依次签名，注意每个用户可以拿到所有人的公钥，但是只持有自己的私钥
 	var sign [64]byte
 	var err error
 	var ret bool
 	for i, privateKey := range privateKeys  {
-		ret, err = multisign.VerifySignInput(publicKeys[:i], publicKeys, message, sign)
+		ret, err = multisign.VerifySignInput(publicKeys[:i], publicNonces[:i], publicKeys, publicNonces, message, sign)
 		if err != nil {
 			panic(err)
 		}
 		if !ret {
 			panic("验证前置签名失败")
 		}
-		sign, err = multisign.AppendSignature(sign, message, privateKey, publicKeys, i)
+		sign, err = multisign.AppendSignature(sign, message, privateKey, k0s[i], publicKeys, publicNonces, i)
 		if err != nil {
 			panic(err)
 		}
-		ret, err = multisign.VerifySignInput(publicKeys[:i+1], publicKeys, message, sign)
+		ret, err = multisign.VerifySignInput(publicKeys[:i+1], publicNonces[:i+1], publicKeys, publicNonces, message, sign)
 		if err != nil {
 			panic(err)
 		}
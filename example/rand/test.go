@@ -1,7 +1,6 @@
 package main
 
 import (
-	"math/big"
 	"schnorr/schnorr-go/multisign"
 	"schnorr/schnorr-go/schnorr"
 )
@@ -11,39 +10,52 @@ func main()  {
 	message := []byte("test msg")
 	var privateKeys [][32]byte
 	var publicKeys [][33]byte
-	Rx, Ry := big.NewInt(0), big.NewInt(0)
+	var k0s [][32]byte
+	var publicNonces [][33]byte
+	var R schnorr.Point
 	for i := 0; i < 10; i++{
 		privateKey, publicKey := schnorr.GenKey()
 		privateKeys = append(privateKeys, privateKey)
 		publicKeys = append(publicKeys, publicKey)
-		pubR := schnorr.GetPublicR(publicKey, message)
-		RIx, RIy := schnorr.Unmarshal(schnorr.Curve, pubR[:])
-		Rx, Ry = schnorr.Curve.Add(Rx, Ry, RIx, RIy)
+		k0, pubR, err := multisign.GenNonce(privateKey, message)
+		if err != nil {
+			panic(err)
+		}
+		k0s = append(k0s, k0)
+		publicNonces = append(publicNonces, pubR)
+		RI, err := new(schnorr.Point).SetBytes(pubR[:])
+		if err != nil {
+			panic(err)
+		}
+		R.Add(&R, RI)
 	}
 
-	// 开始每个用户依次签名，注意每个用户可以拿到所有人的私钥，但是只持有自己的私钥
+	// 开始每个用户依次签名，注意每个用户可以拿到所有人的公钥，但是只持有自己的私钥
 	var err error
 	var ret bool
-	s := big.NewInt(0)
+	var s schnorr.Scalar
 	for i, privateKey := range privateKeys  {
-		signI, err := multisign.Sign(message, privateKey, publicKeys)
+		signI, err := multisign.Sign(message, privateKey, k0s[i], publicKeys, publicNonces)
 		if err != nil {
 			panic(err)
 		}
-		ret, err = multisign.VerifySignInput(publicKeys[i:i+1], publicKeys, message, signI)
+		ret, err = multisign.VerifySignInput(publicKeys[i:i+1], publicNonces[i:i+1], publicKeys, publicNonces, message, signI)
 		if err != nil {
 			panic(err)
 		}
 		if !ret {
 			panic("验证签名失败")
 		}
-		s = new(big.Int).Add(new(big.Int).SetBytes(signI[32:]), s)
-		s = s.Mod(s, schnorr.Curve.N)
+		var sI [32]byte
+		copy(sI[:], signI[32:])
+		si, err := schnorr.NewScalar(sI)
+		if err != nil {
+			panic(err)
+		}
+		s.Add(&s, si)
 	}
 
-	var sign [64]byte
-	copy(sign[:32], schnorr.IntToByte(Rx))
-	copy(sign[32:], schnorr.IntToByte(s))
+	sign := schnorr.NewSignature(&R, &s)
 
 	//所有人都签名完了，验证签名
 	ret, err = multisign.MultiVerify(publicKeys, message, sign)
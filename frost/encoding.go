@@ -0,0 +1,59 @@
+package frost
+
+import (
+	"encoding/binary"
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+)
+
+const keyShareVersion = 1
+
+// keyShareSize version(1) || ID(4) || Threshold(4) || Secret(32) || Public(33) || GroupKey(33)
+const keyShareSize = 1 + 4 + 4 + 32 + 33 + 33
+
+// MarshalBinary 序列化私钥分片，结果包含私钥，需要妥善保存
+func (s *KeyShare) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, keyShareSize)
+	buf = append(buf, keyShareVersion)
+	buf = append(buf, encodeID(s.ID)...)
+	buf = append(buf, encodeID(uint32(s.Threshold))...)
+	buf = append(buf, s.Secret[:]...)
+	buf = append(buf, s.Public[:]...)
+	buf = append(buf, s.GroupKey[:]...)
+	return buf, nil
+}
+
+// UnmarshalBinary 解析 MarshalBinary 的结果，并检查 Public == Secret*G
+func (s *KeyShare) UnmarshalBinary(data []byte) error {
+	if len(data) != keyShareSize {
+		return errors.New("invalid key share length")
+	}
+	if data[0] != keyShareVersion {
+		return errors.New("unsupported key share version")
+	}
+	id := binary.BigEndian.Uint32(data[1:5])
+	threshold := int(binary.BigEndian.Uint32(data[5:9]))
+	if threshold < 1 {
+		return errors.New("invalid threshold")
+	}
+	var groupKey [33]byte
+	copy(groupKey[:], data[74:])
+	if x, _ := schnorr.Unmarshal(schnorr.Curve, groupKey[:]); x == nil {
+		return errors.New("invalid group key")
+	}
+	var b [32]byte
+	copy(b[:], data[9:41])
+	secret, err := schnorr.NewScalar(b)
+	if err != nil {
+		return errors.New("invalid share")
+	}
+	share, err := NewKeyShare(id, threshold, secret, groupKey)
+	if err != nil {
+		return err
+	}
+	if string(share.Public[:]) != string(data[41:74]) {
+		return errors.New("public share does not match secret")
+	}
+	*s = *share
+	return nil
+}
@@ -0,0 +1,153 @@
+package frost
+
+import (
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+// signWith 由 signers 中的参与者完成一次签名
+func signWith(t *testing.T, shares []*KeyShare, pub *PublicKeyPackage, signers []int, message []byte) [64]byte {
+	var nonces []*SigningNonces
+	pkg := &SigningPackage{Message: message}
+	for _, i := range signers {
+		n, c, err := Commit(shares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, n)
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+	var sigShares []SignatureShare
+	for j, i := range signers {
+		sigShare, err := Sign(pkg, nonces[j], shares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyShare(pkg, pub, sigShare); err != nil {
+			t.Fatal(err)
+		}
+		sigShares = append(sigShares, sigShare)
+	}
+	signature, err := Aggregate(pkg, pub, sigShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestThresholdSign(t *testing.T) {
+	message := []byte("test msg")
+	shares, pub, commitment, err := TrustedDealerKeyGen(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		if err := commitment.VerifyShare(share.ID, share.Secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, signers := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		signature := signWith(t, shares, pub, signers, message)
+		ret, err := schnorr.Verify(pub.GroupKey, message, signature)
+		if err != nil || !ret {
+			t.Fatal("signature verification failed", signers, err)
+		}
+	}
+}
+
+func TestSplitSecret(t *testing.T) {
+	d, P := schnorr.GenKey()
+	shares, pub, _, err := SplitSecret(d, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.GroupKey != P {
+		t.Fatal("group key is not equal to public key")
+	}
+	message := []byte("test msg")
+	signature := signWith(t, shares, pub, []int{2, 1}, message)
+	if ret, _ := schnorr.Verify(P, message, signature); !ret {
+		t.Fatal("signature verification failed")
+	}
+}
+
+func TestNotEnoughSigners(t *testing.T) {
+	shares, _, _, err := TrustedDealerKeyGen(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &SigningPackage{Message: []byte("test msg")}
+	var nonces []*SigningNonces
+	for _, share := range shares[:2] {
+		n, c, err := Commit(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, n)
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+	if _, err := Sign(pkg, nonces[0], shares[0]); err == nil {
+		t.Fatal("signing with less than threshold signers should fail")
+	}
+}
+
+func TestBadShare(t *testing.T) {
+	message := []byte("test msg")
+	shares, pub, _, err := TrustedDealerKeyGen(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &SigningPackage{Message: message}
+	var nonces []*SigningNonces
+	for _, share := range shares[:2] {
+		n, c, err := Commit(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, n)
+		pkg.Commitments = append(pkg.Commitments, c)
+	}
+	var sigShares []SignatureShare
+	for i, share := range shares[:2] {
+		sigShare, err := Sign(pkg, nonces[i], share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigShares = append(sigShares, sigShare)
+	}
+	sigShares[1].Z[31] ^= 1
+	if err := VerifyShare(pkg, pub, sigShares[1]); err == nil {
+		t.Fatal("corrupted share should be rejected")
+	}
+	if _, err := Aggregate(pkg, pub, sigShares); err == nil {
+		t.Fatal("aggregate with corrupted share should fail")
+	}
+
+	// 随机数只能使用一次
+	if _, err := Sign(pkg, nonces[0], shares[0]); err == nil {
+		t.Fatal("nonce reuse should fail")
+	}
+}
+
+func TestKeyShareEncoding(t *testing.T) {
+	shares, _, _, err := TrustedDealerKeyGen(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := shares[1].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var share KeyShare
+	if err := share.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if share != *shares[1] {
+		t.Fatal("decoded key share is not equal")
+	}
+	data[20] ^= 1
+	if err := share.UnmarshalBinary(data); err == nil {
+		t.Fatal("tampered key share should be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package frost
+
+import (
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+)
+
+// TrustedDealerKeyGen 由可信的分发者生成群私钥，并分成 n 个分片，任意 threshold 个分片可以签名
+// 返回每个参与者的分片(ID 依次为 1..n)、公开信息和 Feldman 承诺
+// 参与者收到分片后应该用 Commitment.VerifyShare 检查
+func TrustedDealerKeyGen(threshold, n int) ([]*KeyShare, *PublicKeyPackage, Commitment, error) {
+	secret, err := schnorr.RandomScalar()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return SplitSecret(secret.Bytes(), threshold, n)
+}
+
+// SplitSecret 把已有的私钥分成 n 个分片，群公钥为 secret*G
+func SplitSecret(secret [32]byte, threshold, n int) ([]*KeyShare, *PublicKeyPackage, Commitment, error) {
+	if threshold < 1 || threshold > n {
+		return nil, nil, nil, errors.New("invalid threshold")
+	}
+	if n >= 1<<31 {
+		return nil, nil, nil, errors.New("too many participants")
+	}
+	a0, err := schnorr.NewScalar(secret)
+	if err != nil || a0.IsZero() {
+		return nil, nil, nil, errors.New("invalid private key")
+	}
+	f, err := randPolynomial(a0, threshold)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.clear()
+	commitment := f.commit()
+
+	pub := &PublicKeyPackage{
+		Threshold: threshold,
+		GroupKey:  commitment[0],
+		Shares:    make(map[uint32][33]byte),
+	}
+	var shares []*KeyShare
+	for i := 1; i <= n; i++ {
+		id := uint32(i)
+		share, err := NewKeyShare(id, threshold, f.evaluate(id), commitment[0])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		shares = append(shares, share)
+		pub.Shares[id] = share.Public
+	}
+	return shares, pub, commitment, nil
+}
+
+// NewKeyShare 由分片 secret 组装 KeyShare，计算对应的公钥
+func NewKeyShare(id uint32, threshold int, secret *schnorr.Scalar, groupKey [33]byte) (*KeyShare, error) {
+	if id == 0 {
+		return nil, errors.New("invalid identifier")
+	}
+	if secret.IsZero() {
+		return nil, errors.New("invalid share")
+	}
+	return &KeyShare{
+		ID:        id,
+		Threshold: threshold,
+		Secret:    secret.Bytes(),
+		Public:    new(schnorr.Point).BaseMul(secret).Bytes(),
+		GroupKey:  groupKey,
+	}, nil
+}
+
+// randPolynomial 常数项为 a0 的随机 t-1 次多项式
+func randPolynomial(a0 *schnorr.Scalar, threshold int) (polynomial, error) {
+	f := polynomial{new(schnorr.Scalar).Set(a0)}
+	for i := 1; i < threshold; i++ {
+		a, err := schnorr.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, a)
+	}
+	return f, nil
+}
@@ -0,0 +1,309 @@
+package frost
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"sort"
+	"sync"
+)
+
+const (
+	nonceTag      = "schnorr-go/frost/nonce"
+	messageTag    = "schnorr-go/frost/msg"
+	commitmentTag = "schnorr-go/frost/com"
+	bindingTag    = "schnorr-go/frost/rho"
+)
+
+// SigningCommitment 第一轮，参与者公开的随机数承诺 D = d*G, E = e*G
+type SigningCommitment struct {
+	ID      uint32
+	Hiding  [33]byte
+	Binding [33]byte
+}
+
+// SigningNonces 第一轮生成的随机数 (d, e)，自己保存，只能用于一次签名
+type SigningNonces struct {
+	mu         sync.Mutex
+	hiding     [32]byte
+	binding    [32]byte
+	commitment SigningCommitment
+}
+
+// SigningPackage 协调者收集的本次签名信息，所有签名者必须一致
+// Commitments 是参与本次签名的参与者的承诺，至少 threshold 个
+type SigningPackage struct {
+	Message     []byte
+	Commitments []SigningCommitment
+}
+
+// SignatureShare 第二轮，参与者的签名分片
+type SignatureShare struct {
+	ID uint32
+	Z  [32]byte
+}
+
+// Commit 第一轮，生成本次签名的随机数和承诺，承诺发给协调者
+// d = TaggedHash("schnorr-go/frost/nonce", rand||secret)，不在 [1, N) 中时重新生成，e 同理
+func Commit(share *KeyShare) (*SigningNonces, SigningCommitment, error) {
+	nonces := &SigningNonces{}
+	if err := nonceGenerate(share.Secret, nonces.hiding[:]); err != nil {
+		return nil, SigningCommitment{}, err
+	}
+	if err := nonceGenerate(share.Secret, nonces.binding[:]); err != nil {
+		return nil, SigningCommitment{}, err
+	}
+	d, err := schnorr.NewScalar(nonces.hiding)
+	if err != nil {
+		return nil, SigningCommitment{}, err
+	}
+	e, err := schnorr.NewScalar(nonces.binding)
+	if err != nil {
+		return nil, SigningCommitment{}, err
+	}
+	c := SigningCommitment{
+		ID:      share.ID,
+		Hiding:  new(schnorr.Point).BaseMul(d).Bytes(),
+		Binding: new(schnorr.Point).BaseMul(e).Bytes(),
+	}
+	nonces.commitment = c
+	return nonces, c, nil
+}
+
+// Commitment 随机数对应的承诺
+func (n *SigningNonces) Commitment() SigningCommitment {
+	return n.commitment
+}
+
+// take 取出随机数并清零，同一组随机数不能签第二次
+func (n *SigningNonces) take() (d, e [32]byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	d, e = n.hiding, n.binding
+	n.hiding, n.binding = [32]byte{}, [32]byte{}
+	return d, e
+}
+
+// Sign 第二轮，生成签名分片 z = d + e*rho + λ*s*c
+// nonces 使用后清零，随机数和私钥分片只用固定时间的 Scalar 计算
+func Sign(pkg *SigningPackage, nonces *SigningNonces, share *KeyShare) (SignatureShare, error) {
+	ctx, err := newSigningContext(pkg, share.GroupKey, share.Threshold)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+	own, ok := ctx.commitments[share.ID]
+	if !ok || own != nonces.commitment {
+		return SignatureShare{}, errors.New("commitment is not in signing package")
+	}
+	db, eb := nonces.take()
+	d, errD := schnorr.NewScalar(db)
+	e, errE := schnorr.NewScalar(eb)
+	if errD != nil || errE != nil || d.IsZero() || e.IsZero() {
+		return SignatureShare{}, errors.New("nonce already used")
+	}
+	if ctx.negate {
+		d.Neg(d)
+		e.Neg(e)
+	}
+	lambda, err := lagrange(share.ID, ctx.ids)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+	s, err := schnorr.NewScalar(share.Secret)
+	if err != nil {
+		return SignatureShare{}, errors.New("invalid share")
+	}
+
+	z := new(schnorr.Scalar).SetBig(lambda)
+	z.Mul(z, s)
+	z.Mul(z, new(schnorr.Scalar).SetBig(ctx.c))
+	z.Add(z, d)
+	z.Add(z, e.Mul(e, new(schnorr.Scalar).SetBig(ctx.rho[share.ID])))
+	return SignatureShare{ID: share.ID, Z: z.Bytes()}, nil
+}
+
+// VerifyShare 验证一个签名分片 z*G == ±(D + rho*E) + c*λ*Y
+// 协调者用它找出提交错误分片的参与者
+func VerifyShare(pkg *SigningPackage, pub *PublicKeyPackage, sigShare SignatureShare) error {
+	ctx, err := newSigningContext(pkg, pub.GroupKey, pub.Threshold)
+	if err != nil {
+		return err
+	}
+	return ctx.verifyShare(pub, sigShare)
+}
+
+// Aggregate 检查所有签名分片并聚合成最终签名 (Rx, s)，s = Σ zi
+// 签名可以用 schnorr.Verify 对 pub.GroupKey 验证
+func Aggregate(pkg *SigningPackage, pub *PublicKeyPackage, sigShares []SignatureShare) (signature [64]byte, err error) {
+	ctx, err := newSigningContext(pkg, pub.GroupKey, pub.Threshold)
+	if err != nil {
+		return signature, err
+	}
+	if len(sigShares) != len(ctx.ids) {
+		return signature, errors.New("sigShares size is not equal to commitments")
+	}
+	seen := make(map[uint32]bool)
+	s := new(big.Int)
+	for _, sigShare := range sigShares {
+		if seen[sigShare.ID] {
+			return signature, errors.New("duplicate signature share")
+		}
+		seen[sigShare.ID] = true
+		if err := ctx.verifyShare(pub, sigShare); err != nil {
+			return signature, err
+		}
+		s.Add(s, new(big.Int).SetBytes(sigShare.Z[:]))
+	}
+	s.Mod(s, schnorr.Curve.N)
+	copy(signature[:32], schnorr.IntToByte(ctx.rx))
+	copy(signature[32:], schnorr.IntToByte(s))
+	return signature, nil
+}
+
+// signingContext 由 SigningPackage 计算出的本次签名共同的参数
+type signingContext struct {
+	ids         []uint32
+	commitments map[uint32]SigningCommitment
+	rho         map[uint32]*big.Int
+	rx          *big.Int
+	negate      bool
+	c           *big.Int
+}
+
+// newSigningContext 计算每个参与者的 binding factor rho、群承诺 R 和挑战值 c
+// rho_i = TaggedHash("schnorr-go/frost/rho", P||H(msg)||H(commitments)||i) mod N
+// R = Σ (Di + rho_i*Ei)，Ry 不是二次剩余时取 -R
+// c = schnorr.Challenge(Rx, P, msg)
+func newSigningContext(pkg *SigningPackage, groupKey [33]byte, threshold int) (*signingContext, error) {
+	if len(pkg.Commitments) < threshold || len(pkg.Commitments) == 0 {
+		return nil, errors.New("not enough signers")
+	}
+	if Px, _ := schnorr.Unmarshal(schnorr.Curve, groupKey[:]); Px == nil {
+		return nil, errors.New("invalid group key")
+	}
+	ctx := &signingContext{
+		commitments: make(map[uint32]SigningCommitment),
+		rho:         make(map[uint32]*big.Int),
+	}
+	commitments := append([]SigningCommitment{}, pkg.Commitments...)
+	sort.Slice(commitments, func(i, j int) bool {
+		return commitments[i].ID < commitments[j].ID
+	})
+	var encoded []byte
+	for i, c := range commitments {
+		if c.ID == 0 {
+			return nil, errors.New("invalid identifier")
+		}
+		if i > 0 && commitments[i-1].ID == c.ID {
+			return nil, errors.New("duplicate identifier")
+		}
+		ctx.ids = append(ctx.ids, c.ID)
+		ctx.commitments[c.ID] = c
+		encoded = append(encoded, encodeID(c.ID)...)
+		encoded = append(encoded, c.Hiding[:]...)
+		encoded = append(encoded, c.Binding[:]...)
+	}
+
+	N := schnorr.Curve.N
+	msgHash := schnorr.TaggedHash(messageTag, pkg.Message)
+	comHash := schnorr.TaggedHash(commitmentTag, encoded)
+	Rx, Ry := schnorr.Zero, schnorr.Zero
+	for _, c := range commitments {
+		h := schnorr.TaggedHash(bindingTag, groupKey[:], msgHash[:], comHash[:], encodeID(c.ID))
+		rho := new(big.Int).SetBytes(h[:])
+		rho.Mod(rho, N)
+		ctx.rho[c.ID] = rho
+
+		Cx, Cy, err := c.point(rho)
+		if err != nil {
+			return nil, err
+		}
+		Rx, Ry = schnorr.Curve.Add(Rx, Ry, Cx, Cy)
+	}
+	if Rx.Sign() == 0 && Ry.Sign() == 0 {
+		return nil, errors.New("group commitment is infinity")
+	}
+	ctx.rx = Rx
+	ctx.negate = big.Jacobi(Ry, schnorr.Curve.P) != 1
+
+	var r [32]byte
+	copy(r[:], schnorr.IntToByte(Rx))
+	ctx.c = schnorr.Challenge(r, groupKey, pkg.Message)
+	return ctx, nil
+}
+
+func (ctx *signingContext) verifyShare(pub *PublicKeyPackage, sigShare SignatureShare) error {
+	c, ok := ctx.commitments[sigShare.ID]
+	if !ok {
+		return errors.New("signature share is not in signing package")
+	}
+	Y, ok := pub.Shares[sigShare.ID]
+	if !ok {
+		return errors.New("unknown participant")
+	}
+	Yx, Yy := schnorr.Unmarshal(schnorr.Curve, Y[:])
+	if Yx == nil {
+		return errors.New("invalid public share")
+	}
+	z := new(big.Int).SetBytes(sigShare.Z[:])
+	if z.Cmp(schnorr.Curve.N) >= 0 {
+		return errors.New("invalid signature share")
+	}
+
+	Rx, Ry, err := c.point(ctx.rho[sigShare.ID])
+	if err != nil {
+		return err
+	}
+	if ctx.negate {
+		Ry = new(big.Int).Sub(schnorr.Curve.P, Ry)
+	}
+	lambda, err := lagrange(sigShare.ID, ctx.ids)
+	if err != nil {
+		return err
+	}
+	k := lambda.Mul(lambda, ctx.c)
+	k.Mod(k, schnorr.Curve.N)
+	kYx, kYy := schnorr.Curve.ScalarMult(Yx, Yy, schnorr.IntToByte(k))
+	Rx, Ry = schnorr.Curve.Add(Rx, Ry, kYx, kYy)
+
+	zGx, zGy := schnorr.Curve.ScalarBaseMult(sigShare.Z[:])
+	if zGx.Cmp(Rx) != 0 || zGy.Cmp(Ry) != 0 {
+		return errors.New("signature share verification failed")
+	}
+	return nil
+}
+
+// point 计算 D + rho*E
+func (c SigningCommitment) point(rho *big.Int) (x, y *big.Int, err error) {
+	Dx, Dy := schnorr.Unmarshal(schnorr.Curve, c.Hiding[:])
+	Ex, Ey := schnorr.Unmarshal(schnorr.Curve, c.Binding[:])
+	if Dx == nil || Ex == nil {
+		return nil, nil, errors.New("invalid commitment")
+	}
+	Ex, Ey = schnorr.Curve.ScalarMult(Ex, Ey, schnorr.IntToByte(rho))
+	x, y = schnorr.Curve.Add(Dx, Dy, Ex, Ey)
+	return x, y, nil
+}
+
+func encodeID(id uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], id)
+	return b[:]
+}
+
+// nonceGenerate 随机数同时依赖系统随机数和私钥分片，系统随机数出问题时不会直接泄露私钥
+func nonceGenerate(secret [32]byte, out []byte) error {
+	for {
+		var r [32]byte
+		if _, err := rand.Read(r[:]); err != nil {
+			return err
+		}
+		h := schnorr.TaggedHash(nonceTag, r[:], secret[:])
+		if k, err := schnorr.NewScalar(h); err == nil && !k.IsZero() {
+			copy(out, h[:])
+			return nil
+		}
+	}
+}
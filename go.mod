@@ -2,4 +2,8 @@ module schnorr/schnorr-go
 
 go 1.13
 
-require github.com/btcsuite/btcd v0.20.1-beta
+require (
+	github.com/btcsuite/btcd v0.20.1-beta
+	github.com/btcsuite/btcutil v1.0.2
+	golang.org/x/crypto v0.0.0-20200115085410-6d4e4cb37c7d
+)
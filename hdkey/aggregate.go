@@ -0,0 +1,45 @@
+package hdkey
+
+import (
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+)
+
+// AggregateChainCode BIP-328 为聚合公钥规定的链码，委员会没有约定其他链码时使用
+var AggregateChainCode = [32]byte{
+	0x86, 0x80, 0x87, 0xca, 0x02, 0xa6, 0xf9, 0x74, 0xc4, 0x59, 0x89, 0x24, 0xc3, 0x6b, 0x57, 0x76,
+	0x2d, 0x32, 0xcb, 0x45, 0x71, 0x71, 0x67, 0xe3, 0x00, 0x62, 0x2c, 0x71, 0x67, 0xe3, 0x89, 0x65,
+}
+
+// NewAggregateKey 把多人的聚合公钥当作扩展公钥(BIP-328)，深度和父指纹都为0
+// publicKey 是没有调整过的聚合公钥，例如 schnorr KeyAggContext.AggregateKey 或 musig2 KeyAggContext.PlainPubKey
+// 得到的 xpub 可以交给只读的服务，不联系签名者就能用 Derive 生成新的子公钥，只能做非强化派生
+func NewAggregateKey(publicKey [33]byte, chainCode [32]byte) (*ExtendedKey, error) {
+	if _, err := new(schnorr.Point).SetBytes(publicKey[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return &ExtendedKey{Version: MainNetPublic, ChainCode: chainCode, Key: publicKey}, nil
+}
+
+// DeriveTweak 同 Derive，同时返回子公钥相对 k 的调整值 t，子公钥 = k 的公钥 + t*G
+// 签名者用 schnorr.WithPlainTweak(t) (或 musig2 的 ApplyTweak(t, false)) 对子公钥签名
+// path 中不能有强化派生
+func (k *ExtendedKey) DeriveTweak(path string) (child *ExtendedKey, t [32]byte, err error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, t, err
+	}
+	var sum schnorr.Scalar
+	child = k
+	for _, i := range indices {
+		if i >= HardenedKeyStart {
+			return nil, t, ErrDeriveHardenedFromPublic
+		}
+		var il *schnorr.Scalar
+		if child, il, err = child.child(i); err != nil {
+			return nil, t, err
+		}
+		sum.Add(&sum, il)
+	}
+	return child, sum.Bytes(), nil
+}
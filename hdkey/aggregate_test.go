@@ -0,0 +1,173 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"errors"
+	"schnorr/schnorr-go/musig2"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+type testCommittee struct {
+	privateKeys [][32]byte
+	publicKeys  [][33]byte
+}
+
+func newTestCommittee(n int) *testCommittee {
+	c := &testCommittee{}
+	for i := 0; i < n; i++ {
+		d, P := schnorr.GenKey()
+		c.privateKeys = append(c.privateKeys, d)
+		c.publicKeys = append(c.publicKeys, P)
+	}
+	return c
+}
+
+// sign 所有人依次签名
+func (c *testCommittee) sign(t *testing.T, message []byte, opts ...schnorr.Option) [64]byte {
+	var k0s [][32]byte
+	var publicKeys []*schnorr.PublicKey
+	for i, d := range c.privateKeys {
+		k0, R, err := schnorr.GenNonce(d, message, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k0s = append(k0s, k0)
+		publicKeys = append(publicKeys, &schnorr.PublicKey{P: c.publicKeys[i], R: R})
+	}
+	var signature [64]byte
+	for i, d := range c.privateKeys {
+		var err error
+		signature, err = schnorr.AppendSignature(signature, message, &schnorr.PrivateKey{D: d, K0: k0s[i]}, publicKeys, i, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signature
+}
+
+func TestAggregateKey(t *testing.T) {
+	c := newTestCommittee(3)
+	mode := schnorr.WithAggregation(schnorr.AggregationHardened)
+	ctx, err := schnorr.NewKeyAggContext(c.publicKeys, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := NewAggregateKey(ctx.AggregateKey(), AggregateChainCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只读服务由 xpub 派生存款地址
+	watch, err := Parse(xpub.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("test msg")
+	for _, path := range []string{"m", "m/0/0", "m/0/1", "m/1/7/2147483647"} {
+		deposit, err := watch.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 签名者得到同一个子公钥和对应的调整值
+		child, tweak, err := xpub.DeriveTweak(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if child.String() != deposit.String() {
+			t.Fatal("derived key mismatch", path)
+		}
+		opts := []schnorr.Option{mode, schnorr.WithPlainTweak(tweak)}
+		tweaked, err := schnorr.NewKeyAggContext(c.publicKeys, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tweaked.PublicKey() != deposit.PublicKey() || tweaked.AggregateKey() != ctx.AggregateKey() {
+			t.Fatal("tweaked aggregate key mismatch", path)
+		}
+		signature := c.sign(t, message, opts...)
+		if ret, err := schnorr.Verify(deposit.PublicKey(), message, signature); err != nil || !ret {
+			t.Fatal("signature is not valid under the derived key", path, err)
+		}
+		if ret, err := schnorr.MultiVerify(c.publicKeys, message, signature, opts...); err != nil || !ret {
+			t.Fatal("multi verification failed", path, err)
+		}
+
+		// 子公钥作为 Taproot 内部公钥
+		opts = append(opts, schnorr.WithTweak(nil))
+		taproot, err := schnorr.NewKeyAggContext(c.publicKeys, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Q, err := schnorr.TweakPublicKey(deposit.PublicKey(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if taproot.InternalKey() != deposit.PublicKey() || taproot.PublicKey() != Q {
+			t.Fatal("taproot key mismatch", path)
+		}
+		signature = c.sign(t, message, opts...)
+		if ret, err := schnorr.Verify(Q, message, signature); err != nil || !ret {
+			t.Fatal("signature is not valid under the taproot key", path, err)
+		}
+	}
+
+	if _, _, err := xpub.DeriveTweak("m/0'"); !errors.Is(err, ErrDeriveHardenedFromPublic) {
+		t.Fatal("hardened derivation should fail")
+	}
+	if _, err := NewAggregateKey([33]byte{2}, AggregateChainCode); !errors.Is(err, ErrInvalidKey) {
+		t.Fatal("invalid aggregate key should fail")
+	}
+}
+
+// musig2 的聚合公钥用同样的方法派生，调整值用 ApplyTweak(t, false)
+func TestAggregateKeyMuSig2(t *testing.T) {
+	c := newTestCommittee(3)
+	keyAgg, err := musig2.KeyAgg(musig2.KeySort(c.publicKeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := NewAggregateKey(keyAgg.PlainPubKey(), AggregateChainCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, tweak, err := xpub.DeriveTweak("m/0/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tweaked, err := keyAgg.ApplyTweak(tweak, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweaked.PlainPubKey() != child.PublicKey() {
+		t.Fatal("musig2 derived key mismatch")
+	}
+}
+
+// BIP-328 的测试向量，公钥按给定的顺序用 BIP-327 KeyAgg 聚合
+func TestAggregateKeyBIP328(t *testing.T) {
+	var publicKeys [][33]byte
+	for _, s := range []string{
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+	} {
+		var P [33]byte
+		b, _ := hex.DecodeString(s)
+		copy(P[:], b)
+		publicKeys = append(publicKeys, P)
+	}
+	keyAgg, err := musig2.KeyAgg(publicKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggregate := keyAgg.PlainPubKey()
+	if hex.EncodeToString(aggregate[:]) != "0354240c76b8f2999143301a99c7f721ee57eee0bce401df3afeaa9ae218c70f23" {
+		t.Fatal("aggregate key mismatch")
+	}
+	xpub, err := NewAggregateKey(aggregate, AggregateChainCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xpub.String() != "xpub661MyMwAqRbcFt6tk3uaczE1y6EvM1TqXvawXcYmFEWijEM4PDBnuCXwwXEKGEouzXE6QLLRxjatMcLLzJ5LV5Nib1BN7vJg6yp45yHHRbm" {
+		t.Fatal("aggregate xpub mismatch", xpub)
+	}
+}
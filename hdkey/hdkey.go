@@ -0,0 +1,323 @@
+// Package hdkey 实现 BIP-32 分层确定性密钥
+//
+// ExtendedKey 由链码和私钥或公钥组成，可以派生子密钥:
+// I = HMAC-SHA512(链码, 0x00||d||i) (强化派生，只能由私钥派生) 或 HMAC-SHA512(链码, P||i)
+// 子私钥 d_i = IL + d，子公钥 P_i = IL*G + P，子链码为 IR
+// 派生出的私钥可以直接作为 schnorr.PrivateKey 的 D，公钥是 33 字节压缩格式，可以直接用于 schnorr 和 multisign
+package hdkey
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// HardenedKeyStart 不小于它的序号使用强化派生
+const HardenedKeyStart uint32 = 0x80000000
+
+const (
+	// MinSeedLen 种子的最短长度
+	MinSeedLen = 16
+	// MaxSeedLen 种子的最长长度
+	MaxSeedLen = 64
+
+	// serializedLen 版本4 深度1 父指纹4 序号4 链码32 密钥33
+	serializedLen = 78
+)
+
+var masterKey = []byte("Bitcoin seed")
+
+// 序列化时使用的版本号
+var (
+	MainNetPrivate = [4]byte{0x04, 0x88, 0xad, 0xe4} // xprv
+	MainNetPublic  = [4]byte{0x04, 0x88, 0xb2, 0x1e} // xpub
+	TestNetPrivate = [4]byte{0x04, 0x35, 0x83, 0x94} // tprv
+	TestNetPublic  = [4]byte{0x04, 0x35, 0x87, 0xcf} // tpub
+)
+
+var (
+	// ErrInvalidSeed 种子长度不在 [MinSeedLen, MaxSeedLen] 内
+	ErrInvalidSeed = errors.New("invalid seed length")
+	// ErrUnusableSeed 种子得到的主私钥为0或者不小于 N，需要换一个种子
+	ErrUnusableSeed = errors.New("unusable seed")
+	// ErrInvalidChild 派生的子密钥不合法(概率低于 2^-127)，应该跳过这个序号
+	ErrInvalidChild = errors.New("invalid child")
+	// ErrDeriveHardenedFromPublic 公钥不能派生强化子密钥
+	ErrDeriveHardenedFromPublic = errors.New("cannot derive a hardened key from a public key")
+	// ErrNotPrivate 扩展公钥没有私钥
+	ErrNotPrivate = errors.New("not a private extended key")
+	// ErrMaxDepth 深度超过255
+	ErrMaxDepth = errors.New("max depth exceeded")
+	// ErrInvalidKey 扩展密钥的编码不合法
+	ErrInvalidKey = errors.New("invalid extended key")
+	// ErrInvalidChecksum 扩展密钥的校验和不对
+	ErrInvalidChecksum = errors.New("invalid checksum")
+	// ErrInvalidPath 派生路径格式不对
+	ErrInvalidPath = errors.New("invalid derivation path")
+)
+
+// ExtendedKey 扩展密钥
+// Key 为 0x00||d 时是扩展私钥，为压缩公钥时是扩展公钥
+type ExtendedKey struct {
+	Version           [4]byte
+	Depth             uint8
+	ParentFingerprint [4]byte
+	ChildNumber       uint32
+	ChainCode         [32]byte
+	Key               [33]byte
+}
+
+// NewMaster 由种子生成主私钥，I = HMAC-SHA512("Bitcoin seed", seed)，版本为 MainNetPrivate
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedLen || len(seed) > MaxSeedLen {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, masterKey)
+	mac.Write(seed)
+	I := mac.Sum(nil)
+	var d [32]byte
+	copy(d[:], I[:32])
+	if k, err := schnorr.NewScalar(d); err != nil || k.IsZero() {
+		return nil, ErrUnusableSeed
+	}
+	key := &ExtendedKey{Version: MainNetPrivate}
+	copy(key.ChainCode[:], I[32:])
+	copy(key.Key[1:], d[:])
+	return key, nil
+}
+
+// IsPrivate 是否是扩展私钥
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.Key[0] == 0
+}
+
+// PrivateKey 32字节私钥，可以直接作为 schnorr.PrivateKey 的 D，扩展公钥返回 ErrNotPrivate
+func (k *ExtendedKey) PrivateKey() (d [32]byte, err error) {
+	if !k.IsPrivate() {
+		return d, ErrNotPrivate
+	}
+	copy(d[:], k.Key[1:])
+	return d, nil
+}
+
+// PublicKey 33字节压缩公钥
+func (k *ExtendedKey) PublicKey() [33]byte {
+	if !k.IsPrivate() {
+		return k.Key
+	}
+	d, _ := k.PrivateKey()
+	s, _ := schnorr.NewScalar(d)
+	return new(schnorr.Point).BaseMul(s).Bytes()
+}
+
+// Fingerprint 公钥 Hash160 的前4字节，子密钥用它标记父密钥
+func (k *ExtendedKey) Fingerprint() (fp [4]byte) {
+	P := k.PublicKey()
+	copy(fp[:], btcutil.Hash160(P[:]))
+	return fp
+}
+
+// Neuter 对应的扩展公钥，版本号换成对应的公钥版本，扩展公钥返回自己的副本
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	pub := *k
+	if !k.IsPrivate() {
+		return &pub
+	}
+	pub.Key = k.PublicKey()
+	switch k.Version {
+	case MainNetPrivate:
+		pub.Version = MainNetPublic
+	case TestNetPrivate:
+		pub.Version = TestNetPublic
+	}
+	return &pub
+}
+
+// Child 派生序号为 i 的子密钥，i >= HardenedKeyStart 时为强化派生，只能由扩展私钥派生
+// 返回 ErrInvalidChild 时应该跳过这个序号使用下一个
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	child, _, err := k.child(i)
+	return child, err
+}
+
+// child 派生子密钥，同时返回 IL，子公钥 = 父公钥 + IL*G
+func (k *ExtendedKey) child(i uint32) (*ExtendedKey, *schnorr.Scalar, error) {
+	if k.Depth == 255 {
+		return nil, nil, ErrMaxDepth
+	}
+	hardened := i >= HardenedKeyStart
+	if hardened && !k.IsPrivate() {
+		return nil, nil, ErrDeriveHardenedFromPublic
+	}
+	P := k.PublicKey()
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	if hardened {
+		mac.Write(k.Key[:])
+	} else {
+		mac.Write(P[:])
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	mac.Write(index[:])
+	I := mac.Sum(nil)
+
+	var il [32]byte
+	copy(il[:], I[:32])
+	tweak, err := schnorr.NewScalar(il)
+	if err != nil {
+		return nil, nil, ErrInvalidChild
+	}
+	child := &ExtendedKey{
+		Version:     k.Version,
+		Depth:       k.Depth + 1,
+		ChildNumber: i,
+	}
+	copy(child.ParentFingerprint[:], btcutil.Hash160(P[:]))
+	copy(child.ChainCode[:], I[32:])
+
+	if k.IsPrivate() {
+		d, _ := k.PrivateKey()
+		parent, _ := schnorr.NewScalar(d)
+		parent.Add(tweak, parent)
+		if parent.IsZero() {
+			return nil, nil, ErrInvalidChild
+		}
+		d = parent.Bytes()
+		copy(child.Key[1:], d[:])
+		return child, tweak, nil
+	}
+	parent, err := new(schnorr.Point).SetBytes(P[:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	Q := new(schnorr.Point).BaseMul(tweak)
+	Q.Add(Q, parent)
+	if Q.IsInfinity() {
+		return nil, nil, ErrInvalidChild
+	}
+	child.Key = Q.Bytes()
+	return child, tweak, nil
+}
+
+// Derive 按路径依次派生，path 格式见 ParsePath，以 "m" 开头时 k 应该是主密钥
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return k.DerivePath(indices)
+}
+
+// DerivePath 按序号依次派生
+func (k *ExtendedKey) DerivePath(indices []uint32) (*ExtendedKey, error) {
+	key := k
+	for _, i := range indices {
+		var err error
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// ParsePath 解析派生路径，例如 "m/86'/0'/0'/0/1"
+// 可以省略开头的 "m"，序号后面的 '、h 或 H 表示强化派生，"m" 表示空路径
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] == "m" {
+		parts = parts[1:]
+	}
+	indices := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		hardened := false
+		if n := len(part); n > 0 && (part[n-1] == '\'' || part[n-1] == 'h' || part[n-1] == 'H') {
+			hardened = true
+			part = part[:n-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(i) >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPath, path)
+		}
+		if hardened {
+			i += uint64(HardenedKeyStart)
+		}
+		indices = append(indices, uint32(i))
+	}
+	return indices, nil
+}
+
+// String base58 编码的扩展密钥，例如 xprv... 或 xpub...，最后4字节是两次 SHA-256 的校验和
+func (k *ExtendedKey) String() string {
+	b := make([]byte, 0, serializedLen+4)
+	b = append(b, k.Version[:]...)
+	b = append(b, k.Depth)
+	b = append(b, k.ParentFingerprint[:]...)
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], k.ChildNumber)
+	b = append(b, index[:]...)
+	b = append(b, k.ChainCode[:]...)
+	b = append(b, k.Key[:]...)
+	b = append(b, checksum(b)...)
+	return base58.Encode(b)
+}
+
+// Parse 解析 String 的结果，检查校验和、版本号和密钥的合法性
+func Parse(s string) (*ExtendedKey, error) {
+	b := base58.Decode(s)
+	if len(b) != serializedLen+4 {
+		return nil, fmt.Errorf("%w: invalid length", ErrInvalidKey)
+	}
+	payload := b[:serializedLen]
+	if !bytes.Equal(checksum(payload), b[serializedLen:]) {
+		return nil, ErrInvalidChecksum
+	}
+	k := &ExtendedKey{}
+	copy(k.Version[:], payload[:4])
+	k.Depth = payload[4]
+	copy(k.ParentFingerprint[:], payload[5:9])
+	k.ChildNumber = binary.BigEndian.Uint32(payload[9:13])
+	copy(k.ChainCode[:], payload[13:45])
+	copy(k.Key[:], payload[45:])
+
+	private := false
+	switch k.Version {
+	case MainNetPrivate, TestNetPrivate:
+		private = true
+	case MainNetPublic, TestNetPublic:
+	default:
+		return nil, fmt.Errorf("%w: unknown version", ErrInvalidKey)
+	}
+	if k.Depth == 0 && (k.ParentFingerprint != [4]byte{} || k.ChildNumber != 0) {
+		return nil, fmt.Errorf("%w: master key with parent", ErrInvalidKey)
+	}
+	if private {
+		if k.Key[0] != 0 {
+			return nil, fmt.Errorf("%w: invalid private key prefix", ErrInvalidKey)
+		}
+		d, _ := k.PrivateKey()
+		if s, err := schnorr.NewScalar(d); err != nil || s.IsZero() {
+			return nil, fmt.Errorf("%w: private key out of range", ErrInvalidKey)
+		}
+		return k, nil
+	}
+	if _, err := new(schnorr.Point).SetBytes(k.Key[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return k, nil
+}
+
+func checksum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:4]
+}
@@ -0,0 +1,262 @@
+package hdkey
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"strconv"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+const (
+	testSeed1 = "000102030405060708090a0b0c0d0e0f"
+	testSeed2 = "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542"
+	testSeed3 = "4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be"
+)
+
+// BIP-32 测试向量 1-3
+var bip32Vectors = []struct {
+	seed, path, xpub, xprv string
+}{
+	{testSeed1, "m",
+		"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"},
+	{testSeed1, "m/0H",
+		"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+		"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"},
+	{testSeed1, "m/0H/1",
+		"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+		"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs"},
+	{testSeed1, "m/0H/1/2H",
+		"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+		"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM"},
+	{testSeed1, "m/0H/1/2H/2",
+		"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+		"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334"},
+	{testSeed1, "m/0H/1/2H/2/1000000000",
+		"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+		"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"},
+	{testSeed2, "m",
+		"xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB",
+		"xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U"},
+	{testSeed2, "m/0",
+		"xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH",
+		"xprv9vHkqa6EV4sPZHYqZznhT2NPtPCjKuDKGY38FBWLvgaDx45zo9WQRUT3dKYnjwih2yJD9mkrocEZXo1ex8G81dwSM1fwqWpWkeS3v86pgKt"},
+	{testSeed2, "m/0/2147483647H",
+		"xpub6ASAVgeehLbnwdqV6UKMHVzgqAG8Gr6riv3Fxxpj8ksbH9ebxaEyBLZ85ySDhKiLDBrQSARLq1uNRts8RuJiHjaDMBU4Zn9h8LZNnBC5y4a",
+		"xprv9wSp6B7kry3Vj9m1zSnLvN3xH8RdsPP1Mh7fAaR7aRLcQMKTR2vidYEeEg2mUCTAwCd6vnxVrcjfy2kRgVsFawNzmjuHc2YmYRmagcEPdU9"},
+	{testSeed2, "m/0/2147483647H/1",
+		"xpub6DF8uhdarytz3FWdA8TvFSvvAh8dP3283MY7p2V4SeE2wyWmG5mg5EwVvmdMVCQcoNJxGoWaU9DCWh89LojfZ537wTfunKau47EL2dhHKon",
+		"xprv9zFnWC6h2cLgpmSA46vutJzBcfJ8yaJGg8cX1e5StJh45BBciYTRXSd25UEPVuesF9yog62tGAQtHjXajPPdbRCHuWS6T8XA2ECKADdw4Ef"},
+	{testSeed2, "m/0/2147483647H/1/2147483646H",
+		"xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL",
+		"xprvA1RpRA33e1JQ7ifknakTFpgNXPmW2YvmhqLQYMmrj4xJXXWYpDPS3xz7iAxn8L39njGVyuoseXzU6rcxFLJ8HFsTjSyQbLYnMpCqE2VbFWc"},
+	{testSeed2, "m/0/2147483647H/1/2147483646H/2",
+		"xpub6FnCn6nSzZAw5Tw7cgR9bi15UV96gLZhjDstkXXxvCLsUXBGXPdSnLFbdpq8p9HmGsApME5hQTZ3emM2rnY5agb9rXpVGyy3bdW6EEgAtqt",
+		"xprvA2nrNbFZABcdryreWet9Ea4LvTJcGsqrMzxHx98MMrotbir7yrKCEXw7nadnHM8Dq38EGfSh6dqA9QWTyefMLEcBYJUuekgW4BYPJcr9E7j"},
+	// 私钥有前导0
+	{testSeed3, "m",
+		"xpub661MyMwAqRbcEZVB4dScxMAdx6d4nFc9nvyvH3v4gJL378CSRZiYmhRoP7mBy6gSPSCYk6SzXPTf3ND1cZAceL7SfJ1Z3GC8vBgp2epUt13",
+		"xprv9s21ZrQH143K25QhxbucbDDuQ4naNntJRi4KUfWT7xo4EKsHt2QJDu7KXp1A3u7Bi1j8ph3EGsZ9Xvz9dGuVrtHHs7pXeTzjuxBrCmmhgC6"},
+	{testSeed3, "m/0H",
+		"xpub68NZiKmJWnxxS6aaHmn81bvJeTESw724CRDs6HbuccFQN9Ku14VQrADWgqbhhTHBaohPX4CjNLf9fq9MYo6oDaPPLPxSb7gwQN3ih19Zm4Y",
+		"xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L"},
+}
+
+func TestBIP32Vectors(t *testing.T) {
+	for _, v := range bip32Vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		master, err := NewMaster(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := master.Derive(v.path)
+		if err != nil {
+			t.Fatal(v.path, err)
+		}
+		if key.String() != v.xprv {
+			t.Fatal("xprv mismatch", v.path, key.String())
+		}
+		pub := key.Neuter()
+		if pub.String() != v.xpub || pub.Neuter().String() != v.xpub {
+			t.Fatal("xpub mismatch", v.path, pub.String())
+		}
+		// 解析后重新编码不变
+		for _, s := range []string{v.xprv, v.xpub} {
+			parsed, err := Parse(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.String() != s {
+				t.Fatal("round trip mismatch", s)
+			}
+		}
+	}
+}
+
+func TestTestNet(t *testing.T) {
+	seed, _ := hex.DecodeString(testSeed1)
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master.Version = TestNetPrivate
+	key, err := master.Derive("m/0'/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.String() != "tprv8e8VYgZxtHsSdGrtvdxYaSrryZGiYviWzGWtDDKTGh5NMXAEB8gYSCLHpFCywNs5uqV7ghRjimALQJkRFZnUrLHpzi2pGkwqLtbubgWuQ8q" {
+		t.Fatal("tprv mismatch", key.String())
+	}
+	if key.Neuter().String() != "tpubDApXh6cD2fZ7WjtgpHd8yrWyYaneiFuRZa7fVjMkgxsmC1QzoXW8cgx9zQFJ81Jx4deRGfRE7yXA9A3STsxXj4CKEZJHYgpMYikkas9DBTP" {
+		t.Fatal("tpub mismatch", key.Neuter().String())
+	}
+}
+
+// 非强化派生时，先派生再 Neuter 和先 Neuter 再派生结果相同
+func TestPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString(testSeed2)
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive("m/86'/0'/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub := account.Neuter()
+	if _, err := xpub.Child(HardenedKeyStart); !errors.Is(err, ErrDeriveHardenedFromPublic) {
+		t.Fatal("hardened derivation from public key should fail")
+	}
+	if _, err := xpub.PrivateKey(); !errors.Is(err, ErrNotPrivate) {
+		t.Fatal("public key has no private key")
+	}
+	if child, _ := xpub.Child(0); child.ParentFingerprint != account.Fingerprint() {
+		t.Fatal("parent fingerprint mismatch")
+	}
+	for i := uint32(0); i < 5; i++ {
+		priv, err := account.DerivePath([]uint32{0, i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := xpub.Derive("0/" + strconv.Itoa(int(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.Neuter().String() != pub.String() {
+			t.Fatal("public derivation mismatch", i)
+		}
+	}
+}
+
+// 派生出的密钥直接用于 schnorr 签名
+func TestSchnorr(t *testing.T) {
+	seed, _ := hex.DecodeString(testSeed1)
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.Derive("m/86'/0'/0'/0/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := key.PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	P := key.PublicKey()
+	message := []byte("test msg")
+	k0, R, err := schnorr.GenNonce(d, message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := schnorr.AppendSignature([64]byte{}, message, &schnorr.PrivateKey{D: d, K0: k0}, []*schnorr.PublicKey{{P: P, R: R}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := schnorr.Verify(P, message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+	xonly, err := schnorr.ToXOnly(key.Neuter().PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bip340, err := schnorr.SignBIP340(d, message, [32]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := schnorr.VerifyBIP340(xonly, message, bip340); err != nil || !ret {
+		t.Fatal("bip340 verification failed", err)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indices, err := ParsePath("m/86'/0h/0H/0/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint32{HardenedKeyStart + 86, HardenedKeyStart, HardenedKeyStart, 0, 1}
+	if len(indices) != len(expected) {
+		t.Fatal("length mismatch")
+	}
+	for i := range expected {
+		if indices[i] != expected[i] {
+			t.Fatal("index mismatch", i)
+		}
+	}
+	if indices, err := ParsePath("m"); err != nil || len(indices) != 0 {
+		t.Fatal("m should be an empty path")
+	}
+	for _, path := range []string{"", "m/", "m//1", "m/x", "m/-1", "m/+1", "m/1''", "m/2147483648", "m/4294967296", "n/1"} {
+		if _, err := ParsePath(path); !errors.Is(err, ErrInvalidPath) {
+			t.Fatal("invalid path should fail", path)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := NewMaster(make([]byte, MinSeedLen-1)); !errors.Is(err, ErrInvalidSeed) {
+		t.Fatal("short seed should fail")
+	}
+	if _, err := NewMaster(make([]byte, MaxSeedLen+1)); !errors.Is(err, ErrInvalidSeed) {
+		t.Fatal("long seed should fail")
+	}
+	key := &ExtendedKey{Version: MainNetPrivate, Depth: 255, Key: [33]byte{0, 1}}
+	if _, err := key.Child(0); !errors.Is(err, ErrMaxDepth) {
+		t.Fatal("depth 256 should fail")
+	}
+
+	if _, err := Parse("xpub1234"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatal("invalid length should fail")
+	}
+	if _, err := Parse("xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EBygr15"); !errors.Is(err, ErrInvalidChecksum) {
+		t.Fatal("bad checksum should fail")
+	}
+	if _, err := Parse("xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ1hr9Rwbk95YadvBkQXxzHBSngB8ndpW6QH7zhhsXZ2jHyZqPjk"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatal("pubkey not on curve should fail")
+	}
+
+	// 修改合法编码中的字段后重新计算校验和(BIP-32 测试向量5中的各类错误)
+	valid := base58.Decode(bip32Vectors[1].xprv)[:serializedLen]
+	N, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	cases := map[string]func(b []byte){
+		"unknown version":        func(b []byte) { copy(b, []byte{0x04, 0x88, 0xad, 0xe5}) },
+		"pubkey version":         func(b []byte) { copy(b, MainNetPublic[:]) },
+		"private key prefix":     func(b []byte) { b[45] = 1 },
+		"zero private key":       func(b []byte) { copy(b[46:], make([]byte, 32)) },
+		"private key N":          func(b []byte) { copy(b[46:], N) },
+		"zero depth with parent": func(b []byte) { b[4] = 0; binary.BigEndian.PutUint32(b[9:], 0) },
+		"zero depth with index":  func(b []byte) { b[4] = 0; copy(b[5:9], make([]byte, 4)) },
+		"invalid public key":     func(b []byte) { copy(b, MainNetPublic[:]); b[45] = 4 },
+	}
+	for name, modify := range cases {
+		b := append([]byte{}, valid...)
+		modify(b)
+		if _, err := Parse(base58.Encode(append(b, checksum(b)...))); !errors.Is(err, ErrInvalidKey) {
+			t.Fatal(name, "should fail")
+		}
+	}
+}
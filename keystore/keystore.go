@@ -0,0 +1,277 @@
+// Package keystore 用口令加密保存私钥
+//
+// 每个私钥保存为一个 JSON 文件:
+// 口令经 scrypt 得到32字节密钥，用 AES-256-GCM 加密私钥，版本号和公钥作为附加数据一起认证
+// 口令错误、密文或参数被修改时解密失败，公钥或指纹被修改时解析失败，不会得到错误的私钥
+// 文件格式见 encryptedKey，version 用于以后修改格式时区分旧文件，不认识的版本直接报错
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+
+	"github.com/btcsuite/btcutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version 当前的文件格式版本
+const Version = 1
+
+const (
+	kdfScrypt    = "scrypt"
+	cipherAESGCM = "aes-256-gcm"
+	aadPrefix    = "schnorr-go/keystore"
+
+	saltLen  = 32
+	keyLen   = 32
+	nonceLen = 12
+	// scrypt 需要 128*r*N 字节内存，解析文件时先检查上限，防止被修改的文件占用过多内存和时间
+	// 上限和 StandardScrypt 相同，为256MB
+	maxScryptMemory = 256 << 20
+	maxScryptP      = 16
+)
+
+var (
+	// ErrDecrypt 口令错误，或者密文、盐、scrypt 参数被修改
+	ErrDecrypt = errors.New("could not decrypt key: wrong passphrase or corrupted file")
+	// ErrInvalidFile 文件不是合法的 JSON 或者字段不合法
+	ErrInvalidFile = errors.New("invalid keystore file")
+	// ErrUnsupportedVersion 文件版本号不支持
+	ErrUnsupportedVersion = errors.New("unsupported keystore version")
+	// ErrInvalidParams scrypt 参数不合法
+	ErrInvalidParams = errors.New("invalid scrypt params")
+)
+
+// ScryptParams scrypt 的参数，N 为2的幂
+type ScryptParams struct {
+	N, R, P int
+}
+
+var (
+	// StandardScrypt 默认参数，约256MB内存，一次解密约1秒
+	StandardScrypt = ScryptParams{N: 1 << 18, R: 8, P: 1}
+	// LightScrypt 内存和时间都较少的参数，用于测试或者性能受限的设备
+	LightScrypt = ScryptParams{N: 1 << 12, R: 8, P: 6}
+)
+
+func (p ScryptParams) check() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 || p.R < 1 || p.P < 1 || p.P > maxScryptP {
+		return ErrInvalidParams
+	}
+	// 分开比较，避免 128*r*N 溢出
+	if p.R > maxScryptMemory/128 || p.N > maxScryptMemory/(128*p.R) {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// KeyInfo 不需要口令就能读出的公开信息
+type KeyInfo struct {
+	Fingerprint [4]byte
+	PublicKey   [33]byte
+}
+
+// Fingerprint 公钥 Hash160 的前4字节，和 hdkey 的 Fingerprint 相同
+func Fingerprint(publicKey [33]byte) (fp [4]byte) {
+	copy(fp[:], btcutil.Hash160(publicKey[:]))
+	return fp
+}
+
+// encryptedKey 文件格式，字节数组都用 hex 编码
+type encryptedKey struct {
+	Version     int        `json:"version"`
+	Fingerprint string     `json:"fingerprint"`
+	PublicKey   string     `json:"publicKey"`
+	Crypto      cryptoJSON `json:"crypto"`
+}
+
+type cryptoJSON struct {
+	Cipher     string    `json:"cipher"`
+	Nonce      string    `json:"nonce"`
+	Ciphertext string    `json:"ciphertext"`
+	KDF        string    `json:"kdf"`
+	KDFParams  kdfParams `json:"kdfparams"`
+}
+
+type kdfParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// decoded 解析并检查过的文件内容
+type decoded struct {
+	info       KeyInfo
+	params     ScryptParams
+	salt       []byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+// Encrypt 用口令加密私钥，返回 JSON 文件内容，每次使用新的随机盐和 nonce
+func Encrypt(d [32]byte, passphrase []byte, params ScryptParams) ([]byte, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	if k, err := schnorr.NewScalar(d); err != nil || k.IsZero() {
+		return nil, fmt.Errorf("%w: invalid private key", schnorr.ErrScalarOutOfRange)
+	}
+	var P [33]byte
+	Px, Py := schnorr.ScalarBaseMult(d)
+	copy(P[:], schnorr.Marshal(schnorr.Curve, Px, Py))
+
+	salt := make([]byte, saltLen)
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, d[:], additionalData(Version, P))
+
+	fp := Fingerprint(P)
+	return json.MarshalIndent(&encryptedKey{
+		Version:     Version,
+		Fingerprint: hex.EncodeToString(fp[:]),
+		PublicKey:   hex.EncodeToString(P[:]),
+		Crypto: cryptoJSON{
+			Cipher:     cipherAESGCM,
+			Nonce:      hex.EncodeToString(nonce),
+			Ciphertext: hex.EncodeToString(ciphertext),
+			KDF:        kdfScrypt,
+			KDFParams: kdfParams{
+				N:    params.N,
+				R:    params.R,
+				P:    params.P,
+				Salt: hex.EncodeToString(salt),
+			},
+		},
+	}, "", "  ")
+}
+
+// Decrypt 用口令解密 Encrypt 的结果，并检查私钥和文件中的公钥一致
+func Decrypt(data []byte, passphrase []byte) (d [32]byte, err error) {
+	k, err := decode(data)
+	if err != nil {
+		return d, err
+	}
+	aead, err := newAEAD(passphrase, k.salt, k.params)
+	if err != nil {
+		return d, err
+	}
+	plaintext, err := aead.Open(nil, k.nonce, k.ciphertext, additionalData(Version, k.info.PublicKey))
+	if err != nil {
+		return d, ErrDecrypt
+	}
+	copy(d[:], plaintext)
+	zero(plaintext)
+
+	Px, Py := schnorr.ScalarBaseMult(d)
+	if !bytes.Equal(schnorr.Marshal(schnorr.Curve, Px, Py), k.info.PublicKey[:]) {
+		d = [32]byte{}
+		return d, fmt.Errorf("%w: private key does not match public key", ErrInvalidFile)
+	}
+	return d, nil
+}
+
+// ReadInfo 读出文件中的指纹和公钥，不需要口令
+func ReadInfo(data []byte) (KeyInfo, error) {
+	k, err := decode(data)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	return k.info, nil
+}
+
+// decode 解析文件，检查版本、算法、各字段的长度以及指纹和公钥一致
+func decode(data []byte) (*decoded, error) {
+	var f encryptedKey
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, f.Version)
+	}
+	if f.Crypto.Cipher != cipherAESGCM || f.Crypto.KDF != kdfScrypt {
+		return nil, fmt.Errorf("%w: unsupported algorithm", ErrInvalidFile)
+	}
+	k := &decoded{
+		params: ScryptParams{N: f.Crypto.KDFParams.N, R: f.Crypto.KDFParams.R, P: f.Crypto.KDFParams.P},
+	}
+	if err := k.params.check(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	fields := []struct {
+		name string
+		hex  string
+		dst  []byte
+		len  int
+	}{
+		{"fingerprint", f.Fingerprint, k.info.Fingerprint[:], 4},
+		{"publicKey", f.PublicKey, k.info.PublicKey[:], 33},
+		{"nonce", f.Crypto.Nonce, nil, nonceLen},
+		{"ciphertext", f.Crypto.Ciphertext, nil, keyLen + 16},
+		{"salt", f.Crypto.KDFParams.Salt, nil, saltLen},
+	}
+	var values [][]byte
+	for _, field := range fields {
+		b, err := hex.DecodeString(field.hex)
+		if err != nil || len(b) != field.len {
+			return nil, fmt.Errorf("%w: invalid %s", ErrInvalidFile, field.name)
+		}
+		copy(field.dst, b)
+		values = append(values, b)
+	}
+	k.nonce, k.ciphertext, k.salt = values[2], values[3], values[4]
+	if _, err := new(schnorr.Point).SetBytes(k.info.PublicKey[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if Fingerprint(k.info.PublicKey) != k.info.Fingerprint {
+		return nil, fmt.Errorf("%w: fingerprint does not match public key", ErrInvalidFile)
+	}
+	return k, nil
+}
+
+// newAEAD 由口令派生 AES-256 密钥，派生出的密钥用完后清零
+func newAEAD(passphrase, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData 版本号和公钥，修改任何一个都会导致解密失败
+func additionalData(version int, publicKey [33]byte) []byte {
+	aad := make([]byte, 0, len(aadPrefix)+4+33)
+	aad = append(aad, aadPrefix...)
+	var v [4]byte
+	binary.BigEndian.PutUint32(v[:], uint32(version))
+	aad = append(aad, v[:]...)
+	return append(aad, publicKey[:]...)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
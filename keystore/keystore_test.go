@@ -0,0 +1,116 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+// testParams 测试中使用的很小的参数，只为了跑得快
+var testParams = ScryptParams{N: 1 << 10, R: 8, P: 1}
+
+func TestEncryptDecrypt(t *testing.T) {
+	d, P := schnorr.GenKey()
+	passphrase := []byte("correct horse battery staple")
+	data, err := Encrypt(d, passphrase, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ReadInfo(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PublicKey != P || info.Fingerprint != Fingerprint(P) {
+		t.Fatal("key info mismatch")
+	}
+	decrypted, err := Decrypt(data, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != d {
+		t.Fatal("decrypted key mismatch")
+	}
+	if _, err := Decrypt(data, []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+		t.Fatal("wrong passphrase should fail")
+	}
+	// 每次加密使用新的盐和 nonce
+	again, err := Encrypt(d, passphrase, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) == string(data) {
+		t.Fatal("encryption should be randomized")
+	}
+
+	if _, err := Encrypt([32]byte{}, passphrase, testParams); !errors.Is(err, schnorr.ErrScalarOutOfRange) {
+		t.Fatal("zero private key should fail")
+	}
+	for _, params := range []ScryptParams{{N: 1000, R: 8, P: 1}, {N: 1 << 19, R: 8, P: 1}, {N: 1 << 10, R: 1 << 20, P: 1}, {N: 1 << 10, R: 0, P: 1}, {N: 1 << 10, R: 8, P: 0}} {
+		if _, err := Encrypt(d, passphrase, params); !errors.Is(err, ErrInvalidParams) {
+			t.Fatal("invalid params should fail", params)
+		}
+	}
+	for _, params := range []ScryptParams{StandardScrypt, LightScrypt} {
+		if err := params.check(); err != nil {
+			t.Fatal("predefined params should be valid", params)
+		}
+	}
+}
+
+// 修改文件中的任何字段都能发现，不会解出错误的私钥
+func TestTamper(t *testing.T) {
+	d, _ := schnorr.GenKey()
+	passphrase := []byte("passphrase")
+	data, err := Encrypt(d, passphrase, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(s string) string {
+		b, _ := hex.DecodeString(s)
+		b[len(b)-1] ^= 1
+		return hex.EncodeToString(b)
+	}
+	_, other := schnorr.GenKey()
+	cases := []struct {
+		name   string
+		modify func(f *encryptedKey)
+		err    error
+	}{
+		{"ciphertext", func(f *encryptedKey) { f.Crypto.Ciphertext = flip(f.Crypto.Ciphertext) }, ErrDecrypt},
+		{"nonce", func(f *encryptedKey) { f.Crypto.Nonce = flip(f.Crypto.Nonce) }, ErrDecrypt},
+		{"salt", func(f *encryptedKey) { f.Crypto.KDFParams.Salt = flip(f.Crypto.KDFParams.Salt) }, ErrDecrypt},
+		{"scrypt params", func(f *encryptedKey) { f.Crypto.KDFParams.P = 2 }, ErrDecrypt},
+		{"public key", func(f *encryptedKey) {
+			f.PublicKey = hex.EncodeToString(other[:])
+			fp := Fingerprint(other)
+			f.Fingerprint = hex.EncodeToString(fp[:])
+		}, ErrDecrypt},
+		{"fingerprint", func(f *encryptedKey) { f.Fingerprint = flip(f.Fingerprint) }, ErrInvalidFile},
+		{"invalid public key", func(f *encryptedKey) { f.PublicKey = "02" + f.PublicKey[2:64] + "00" }, ErrInvalidFile},
+		{"truncated ciphertext", func(f *encryptedKey) { f.Crypto.Ciphertext = f.Crypto.Ciphertext[2:] }, ErrInvalidFile},
+		{"huge scrypt N", func(f *encryptedKey) { f.Crypto.KDFParams.N = 1 << 30 }, ErrInvalidFile},
+		// 超过内存上限的参数在 scrypt 之前就被拒绝，否则会得到 ErrDecrypt
+		{"scrypt memory", func(f *encryptedKey) { f.Crypto.KDFParams.N, f.Crypto.KDFParams.R = 1<<19, 8 }, ErrInvalidFile},
+		{"cipher", func(f *encryptedKey) { f.Crypto.Cipher = "aes-128-ctr" }, ErrInvalidFile},
+		{"version", func(f *encryptedKey) { f.Version = 2 }, ErrUnsupportedVersion},
+	}
+	for _, c := range cases {
+		var f encryptedKey
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatal(err)
+		}
+		c.modify(&f)
+		modified, err := json.Marshal(&f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decrypt(modified, passphrase); !errors.Is(err, c.err) {
+			t.Fatal(c.name, "should fail with", c.err, "got", err)
+		}
+	}
+	if _, err := Decrypt(data[:len(data)-1], passphrase); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("truncated file should fail")
+	}
+}
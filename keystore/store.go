@@ -0,0 +1,241 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"schnorr/schnorr-go/schnorr"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const fileExt = ".json"
+
+var (
+	// ErrKeyNotFound 目录中没有这个公钥的密钥
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists 目录中已经有这个公钥的密钥
+	ErrKeyExists = errors.New("key already exists")
+	// ErrLocked 密钥没有解锁
+	ErrLocked = errors.New("key is locked")
+)
+
+// Store 一个目录中的所有密钥，每个密钥保存为 <公钥>.json
+// 指纹只有4字节，不同的公钥可能相同，只用于显示和 Find 查找，其他方法都用完整的公钥指定密钥
+// 解锁的私钥保存在内存中，Lock 时清零，可以在多个协程中使用
+type Store struct {
+	mu       sync.Mutex
+	dir      string
+	params   ScryptParams
+	unlocked map[[33]byte]*[32]byte
+}
+
+// NewStore 打开目录，不存在时创建(权限0700)，params 用于之后新加密的密钥
+func NewStore(dir string, params ScryptParams) (*Store, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{
+		dir:      dir,
+		params:   params,
+		unlocked: make(map[[33]byte]*[32]byte),
+	}, nil
+}
+
+// Generate 用 schnorr.GenKey 生成新的私钥并加密保存
+func (s *Store) Generate(passphrase []byte) (KeyInfo, error) {
+	d, _ := schnorr.GenKey()
+	defer func() { d = [32]byte{} }()
+	return s.Import(d, passphrase)
+}
+
+// Import 加密保存已有的私钥，公钥相同的文件已经存在时返回 ErrKeyExists
+func (s *Store) Import(d [32]byte, passphrase []byte) (KeyInfo, error) {
+	data, err := Encrypt(d, passphrase, s.params)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	info, err := ReadInfo(data)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	f, err := os.OpenFile(s.path(info.PublicKey), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return KeyInfo{}, ErrKeyExists
+	}
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return KeyInfo{}, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return KeyInfo{}, err
+	}
+	return info, f.Close()
+}
+
+// List 目录中所有密钥的指纹和公钥，按指纹和公钥排序，有文件解析失败时返回错误
+func (s *Store) List() ([]KeyInfo, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []KeyInfo
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileExt) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		info, err := ReadInfo(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		if file.Name() != hex.EncodeToString(info.PublicKey[:])+fileExt {
+			return nil, fmt.Errorf("%s: %w: file name does not match public key", file.Name(), ErrInvalidFile)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if c := bytes.Compare(infos[i].Fingerprint[:], infos[j].Fingerprint[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(infos[i].PublicKey[:], infos[j].PublicKey[:]) < 0
+	})
+	return infos, nil
+}
+
+// Find 指纹为 fp 的所有密钥，用于由显示的指纹找到公钥，没有时返回 ErrKeyNotFound
+func (s *Store) Find(fp [4]byte) ([]KeyInfo, error) {
+	infos, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found []KeyInfo
+	for _, info := range infos {
+		if info.Fingerprint == fp {
+			found = append(found, info)
+		}
+	}
+	if len(found) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return found, nil
+}
+
+// Unlock 用口令解密私钥并保存在内存中，已经解锁时重新检查口令
+func (s *Store) Unlock(publicKey [33]byte, passphrase []byte) error {
+	d, err := s.decrypt(publicKey, passphrase)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.unlocked[publicKey]; ok {
+		*old = [32]byte{}
+	}
+	s.unlocked[publicKey] = d
+	return nil
+}
+
+// Lock 清零并丢弃内存中的私钥
+func (s *Store) Lock(publicKey [33]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.unlocked[publicKey]; ok {
+		*d = [32]byte{}
+		delete(s.unlocked, publicKey)
+	}
+}
+
+// LockAll 锁定所有密钥
+func (s *Store) LockAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for publicKey, d := range s.unlocked {
+		*d = [32]byte{}
+		delete(s.unlocked, publicKey)
+	}
+}
+
+// PrivateKey 已经解锁的私钥，可以直接作为 schnorr.PrivateKey 的 D，没有解锁时返回 ErrLocked
+// 返回的是副本，调用方用完后应该自己清零
+func (s *Store) PrivateKey(publicKey [33]byte) (d [32]byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.unlocked[publicKey]
+	if !ok {
+		return d, ErrLocked
+	}
+	return *key, nil
+}
+
+// ChangePassphrase 用新口令和当前的 scrypt 参数重新加密，先写临时文件再替换，失败时原文件不变
+func (s *Store) ChangePassphrase(publicKey [33]byte, oldPassphrase, newPassphrase []byte) error {
+	d, err := s.decrypt(publicKey, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	defer func() { *d = [32]byte{} }()
+	data, err := Encrypt(*d, newPassphrase, s.params)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(publicKey))
+}
+
+// decrypt 读出并解密公钥为 publicKey 的文件
+func (s *Store) decrypt(publicKey [33]byte, passphrase []byte) (*[32]byte, error) {
+	data, err := ioutil.ReadFile(s.path(publicKey))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := ReadInfo(data)
+	if err != nil {
+		return nil, err
+	}
+	if info.PublicKey != publicKey {
+		return nil, fmt.Errorf("%w: file name does not match public key", ErrInvalidFile)
+	}
+	d := new([32]byte)
+	if *d, err = Decrypt(data, passphrase); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *Store) path(publicKey [33]byte) string {
+	return filepath.Join(s.dir, hex.EncodeToString(publicKey[:])+fileExt)
+}
@@ -0,0 +1,216 @@
+package keystore
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func newTestStore(t *testing.T) (*Store, string) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStore(dir, testParams)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return s, dir
+}
+
+func TestStore(t *testing.T) {
+	s, dir := newTestStore(t)
+	defer os.RemoveAll(dir)
+	passphrase := []byte("passphrase")
+
+	d, P := schnorr.GenKey()
+	imported, err := s.Import(d, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.PublicKey != P {
+		t.Fatal("imported public key mismatch")
+	}
+	if _, err := s.Import(d, passphrase); !errors.Is(err, ErrKeyExists) {
+		t.Fatal("importing twice should fail")
+	}
+	generated, err := s.Generate(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatal("expected 2 keys")
+	}
+	for _, info := range infos {
+		if info != imported && info != generated {
+			t.Fatal("unexpected key in list")
+		}
+	}
+
+	// 解锁后才能取出私钥，锁定后清零
+	pk := imported.PublicKey
+	if _, err := s.PrivateKey(pk); !errors.Is(err, ErrLocked) {
+		t.Fatal("locked key should not be available")
+	}
+	if err := s.Unlock(pk, []byte("wrong")); !errors.Is(err, ErrDecrypt) {
+		t.Fatal("wrong passphrase should fail")
+	}
+	if err := s.Unlock([33]byte{}, passphrase); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatal("unknown public key should fail")
+	}
+	if err := s.Unlock(pk, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := s.PrivateKey(pk); err != nil || key != d {
+		t.Fatal("unlocked key mismatch", err)
+	}
+	held := s.unlocked[pk]
+	s.Lock(pk)
+	if *held != [32]byte{} {
+		t.Fatal("locked key should be zeroed")
+	}
+	if _, err := s.PrivateKey(pk); !errors.Is(err, ErrLocked) {
+		t.Fatal("key should be locked")
+	}
+
+	if err := s.Unlock(generated.PublicKey, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	held = s.unlocked[generated.PublicKey]
+	s.LockAll()
+	if *held != [32]byte{} || len(s.unlocked) != 0 {
+		t.Fatal("LockAll should zero all keys")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	s, dir := newTestStore(t)
+	defer os.RemoveAll(dir)
+	oldPassphrase, newPassphrase := []byte("old"), []byte("new")
+	info, err := s.Generate(oldPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := info.PublicKey
+	if err := s.ChangePassphrase(pk, []byte("wrong"), newPassphrase); !errors.Is(err, ErrDecrypt) {
+		t.Fatal("wrong old passphrase should fail")
+	}
+	if err := s.ChangePassphrase(pk, oldPassphrase, newPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlock(pk, oldPassphrase); !errors.Is(err, ErrDecrypt) {
+		t.Fatal("old passphrase should no longer work")
+	}
+	if err := s.Unlock(pk, newPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	// 没有留下临时文件
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatal("expected only the key file")
+	}
+
+	// 重新打开目录
+	reopened, err := NewStore(dir, testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock(pk, newPassphrase); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreCorrupted(t *testing.T) {
+	s, dir := newTestStore(t)
+	defer os.RemoveAll(dir)
+	passphrase := []byte("passphrase")
+	info, err := s.Generate(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := s.path(info.PublicKey)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 文件被截断
+	if err := ioutil.WriteFile(path, data[:len(data)/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("corrupted file should be reported by List")
+	}
+	if err := s.Unlock(info.PublicKey, passphrase); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("corrupted file should fail to unlock")
+	}
+
+	// 合法的文件放在不对应的文件名下
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	_, other := schnorr.GenKey()
+	if err := ioutil.WriteFile(filepath.Join(dir, hex.EncodeToString(other[:])+fileExt), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("renamed file should be reported by List")
+	}
+	if err := s.Unlock(other, passphrase); !errors.Is(err, ErrInvalidFile) {
+		t.Fatal("renamed file should fail to unlock")
+	}
+}
+
+// 私钥463和14200的公钥指纹相同，两个密钥都能保存，用 Find 按指纹找到
+func TestStoreFingerprintCollision(t *testing.T) {
+	s, dir := newTestStore(t)
+	defer os.RemoveAll(dir)
+	passphrase := []byte("passphrase")
+	var d1, d2 [32]byte
+	binary.BigEndian.PutUint16(d1[30:], 463)
+	binary.BigEndian.PutUint16(d2[30:], 14200)
+	info1, err := s.Import(d1, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2, err := s.Import(d2, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info1.Fingerprint != info2.Fingerprint || info1.PublicKey == info2.PublicKey {
+		t.Fatal("expected a fingerprint collision")
+	}
+	found, err := s.Find(info1.Fingerprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0] == found[1] {
+		t.Fatal("Find should return both keys")
+	}
+	if _, err := s.Find([4]byte{}); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatal("unknown fingerprint should fail")
+	}
+	for _, c := range []struct {
+		info KeyInfo
+		d    [32]byte
+	}{{info1, d1}, {info2, d2}} {
+		if err := s.Unlock(c.info.PublicKey, passphrase); err != nil {
+			t.Fatal(err)
+		}
+		if key, err := s.PrivateKey(c.info.PublicKey); err != nil || key != c.d {
+			t.Fatal("unlocked key mismatch", err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package multisign
+
+import (
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+)
+
+// 多人适配器签名: 所有参与者使用 schnorr.WithAdaptor(T) 调用 Sign / AppendSignature / Session，
+// 得到的签名结果用 PreSignature 转换成预签名，用 PreVerify 验证
+// 知道 t 的人用 schnorr.Adapt 补全成普通签名，之后任何人都可以用 schnorr.Extract 恢复 t
+
+// PreSignature 把使用 schnorr.WithAdaptor 生成的最终签名结果转换成预签名 R||s'
+// publicNonces 是所有参与者的R，R 为它们的和
+func PreSignature(signOutput [64]byte, publicNonces [][33]byte) (preSig [65]byte, err error) {
+	if len(publicNonces) == 0 {
+		return preSig, fmt.Errorf("%w: invalid publicNonces", schnorr.ErrInvalidLength)
+	}
+	Rx, Ry := schnorr.Zero, schnorr.Zero
+	for _, R := range publicNonces {
+		RIx, RIy := schnorr.Unmarshal(schnorr.Curve, R[:])
+		if RIx == nil {
+			return preSig, fmt.Errorf("%w: invalid nonce", schnorr.ErrInvalidPoint)
+		}
+		Rx, Ry = schnorr.Curve.Add(Rx, Ry, RIx, RIy)
+	}
+	if Rx.Sign() == 0 && Ry.Sign() == 0 {
+		return preSig, fmt.Errorf("%w: invalid nonce", schnorr.ErrInvalidPoint)
+	}
+	var r [32]byte
+	copy(r[:], schnorr.IntToByte(Rx))
+	if string(r[:]) != string(signOutput[:32]) {
+		return preSig, fmt.Errorf("%w: publicNonces do not match signature", ErrNonceMismatch)
+	}
+	copy(preSig[:33], schnorr.Marshal(schnorr.Curve, Rx, Ry))
+	copy(preSig[33:], signOutput[32:])
+	return preSig, nil
+}
+
+// PreVerify 验证多人的预签名
+func PreVerify(publicKeys [][33]byte, message []byte, preSig [65]byte, T [33]byte, opts ...schnorr.Option) (bool, error) {
+	return schnorr.MultiPreVerify(publicKeys, message, preSig, T, opts...)
+}
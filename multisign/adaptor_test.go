@@ -0,0 +1,61 @@
+package multisign
+
+import (
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func TestMultiAdaptor(t *testing.T) {
+	message := []byte("swap msg")
+	secret, T := schnorr.GenKey()
+	opts := []schnorr.Option{schnorr.WithAggregation(schnorr.AggregationHardened), schnorr.WithAdaptor(T)}
+
+	var privateKeys [][32]byte
+	var publicKeys [][33]byte
+	for i := 0; i < 3; i++ {
+		d, P := schnorr.GenKey()
+		privateKeys = append(privateKeys, d)
+		publicKeys = append(publicKeys, P)
+	}
+	var k0s [][32]byte
+	var publicNonces [][33]byte
+	for _, d := range privateKeys {
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k0s = append(k0s, k0)
+		publicNonces = append(publicNonces, R)
+	}
+
+	var signOutput [64]byte
+	var err error
+	for i, d := range privateKeys {
+		signOutput, err = AppendSignature(signOutput, message, d, k0s[i], publicKeys, publicNonces, i, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	preSig, err := PreSignature(signOutput, publicNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := PreVerify(publicKeys, message, preSig, T, schnorr.WithAggregation(schnorr.AggregationHardened)); err != nil || !ret {
+		t.Fatal("pre-signature verification failed", err)
+	}
+
+	signature, err := schnorr.Adapt(preSig, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := MultiVerify(publicKeys, message, signature, schnorr.WithAggregation(schnorr.AggregationHardened)); err != nil || !ret {
+		t.Fatal("adapted signature verification failed", err)
+	}
+	extracted, err := schnorr.Extract(signature, preSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracted != secret {
+		t.Fatal("extracted secret mismatch")
+	}
+}
@@ -1,46 +1,48 @@
 package multisign
 
 import (
-	"errors"
+	"crypto/rand"
+	"fmt"
 	"schnorr/schnorr-go/schnorr"
 )
 
+// GenNonce 生成本次签名使用的随机数
+// k0 自己保存，只能用于这一次签名；R 需要发给其他所有参与者
+func GenNonce(privateKey [32]byte, message []byte) (k0 [32]byte, R [33]byte, err error) {
+	var aux [32]byte
+	if _, err = rand.Read(aux[:]); err != nil {
+		return k0, R, err
+	}
+	return schnorr.GenNonce(privateKey, message, aux[:])
+}
+
 // AppendSignature 实现一个聚合签名，可以在一个签名的基础上追加一个签名
 // signInput 是上一个参与者的签名结果，如果本次为第一个，则为空
-// privateKey是私钥，
+// privateKey是私钥，k0是GenNonce生成的随机数
 // message是签名消息
 // publicKeys 是公钥的集合，按照签名顺序排序
+// publicNonces 是每个参与者GenNonce生成的R，和publicKeys一一对应
 // index 当前签名的序号，小于index的已经签完
-func AppendSignature(signInput [64]byte, message []byte, privateKey [32]byte, publicKeys [][33]byte, index int) (signOutput [64]byte, err error){
-	k0 := schnorr.GetPrivateK0(privateKey, message)
+// opts 可选配置，例如 schnorr.WithAggregation(schnorr.AggregationHardened)，所有参与者和验证者必须一致
+func AppendSignature(signInput [64]byte, message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, publicNonces [][33]byte, index int, opts ...schnorr.Option) (signOutput [64]byte, err error){
 	privKey := &schnorr.PrivateKey{D:privateKey, K0:k0}
 
-	if len(publicKeys) == 0 {
-		return signOutput, errors.New("invalid publicKeys")
-	}
-	var pubKeys []*schnorr.PublicKey
-	for _, publicKey := range publicKeys {
-		R := schnorr.GetPublicR(publicKey, message)
-		pubKey := &schnorr.PublicKey{P:publicKey, R:R}
-		pubKeys = append(pubKeys, pubKey)
+	pubKeys, err := toPublicKeys(publicKeys, publicNonces)
+	if err != nil {
+		return signOutput, err
 	}
-	return schnorr.AppendSignature(signInput, message, privKey, pubKeys, index)
+	return schnorr.AppendSignature(signInput, message, privKey, pubKeys, index, opts...)
 }
 
-func Sign(message []byte, privateKey [32]byte, publicKeys [][33]byte) (signOutput [64]byte, err error){
-	k0 := schnorr.GetPrivateK0(privateKey, message)
+// Sign 一个参与者单独签名，结果由某一个参与者相加聚合
+func Sign(message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, publicNonces [][33]byte, opts ...schnorr.Option) (signOutput [64]byte, err error){
 	privKey := &schnorr.PrivateKey{D:privateKey, K0:k0}
 
-	if len(publicKeys) == 0 {
-		return signOutput, errors.New("invalid publicKeys")
-	}
-	var pubKeys []*schnorr.PublicKey
-	for _, publicKey := range publicKeys {
-		R := schnorr.GetPublicR(publicKey, message)
-		pubKey := &schnorr.PublicKey{P:publicKey, R:R}
-		pubKeys = append(pubKeys, pubKey)
+	pubKeys, err := toPublicKeys(publicKeys, publicNonces)
+	if err != nil {
+		return signOutput, err
 	}
-	Rix, _, s, err := schnorr.Sign(message, privKey, pubKeys)
+	Rix, _, s, err := schnorr.Sign(message, privKey, pubKeys, opts...)
 	if err != nil {
 		return signOutput, err
 	}
@@ -55,41 +57,56 @@ func Verify(publicKey [33]byte, message []byte, signature [64]byte) (bool, error
 }
 
 //MultiVerify
-func MultiVerify(publicKey [][33]byte, message []byte, signature [64]byte) (bool, error) {
-	return schnorr.MultiVerify(publicKey, message, signature)
+func MultiVerify(publicKey [][33]byte, message []byte, signature [64]byte, opts ...schnorr.Option) (bool, error) {
+	return schnorr.MultiVerify(publicKey, message, signature, opts...)
 }
 
 //VerifySignInput 验证签名的中间过程
 //publicKeysSigned 已经参与的签名公钥
+//publicNoncesSigned 已经参与的签名公钥对应的R
 //publicKeys	所有参与签名的公钥
+//publicNonces	所有参与签名的公钥对应的R
 //message		签名消息
 //signInput		签名中间结果
-func VerifySignInput(publicKeysSigned [][33]byte, publicKeys [][33]byte, message []byte, signInput [64]byte) (bool, error) {
+//opts			可选配置，必须和签名时一致
+func VerifySignInput(publicKeysSigned [][33]byte, publicNoncesSigned [][33]byte, publicKeys [][33]byte, publicNonces [][33]byte, message []byte, signInput [64]byte, opts ...schnorr.Option) (bool, error) {
 	if len(publicKeysSigned) == 0 {
 		return true, nil //没有签过
 	}
 
-	if len(publicKeys) == 0 {
-		return false, errors.New("invalid publicKeys")
-	}
-
 	if len(publicKeys) < len(publicKeysSigned) {
-		return false, errors.New("publicKeysSigned size bigger than publicKeys")
+		return false, fmt.Errorf("%w: publicKeysSigned size bigger than publicKeys", schnorr.ErrSizeMismatch)
 	}
 
-	var signedPubKeys []*schnorr.PublicKey
-	for _, publicKey := range publicKeysSigned {
-		R := schnorr.GetPublicR(publicKey, message)
-		pubKey := &schnorr.PublicKey{P:publicKey, R:R}
-		signedPubKeys = append(signedPubKeys, pubKey)
+	signedPubKeys, err := toPublicKeys(publicKeysSigned, publicNoncesSigned)
+	if err != nil {
+		return false, err
+	}
+	pubKeys, err := toPublicKeys(publicKeys, publicNonces)
+	if err != nil {
+		return false, err
 	}
 
+	return schnorr.VerifySignInput(signedPubKeys, pubKeys, message, signInput, opts...)
+}
+
+// toPublicKeys 把公钥和对应的R组装成schnorr.PublicKey
+func toPublicKeys(publicKeys [][33]byte, publicNonces [][33]byte) ([]*schnorr.PublicKey, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("%w: invalid publicKeys", schnorr.ErrInvalidLength)
+	}
+	if len(publicKeys) != len(publicNonces) {
+		return nil, fmt.Errorf("%w: publicNonces size is not equal to publicKeys", schnorr.ErrSizeMismatch)
+	}
 	var pubKeys []*schnorr.PublicKey
-	for _, publicKey := range publicKeys {
-		R := schnorr.GetPublicR(publicKey, message)
-		pubKey := &schnorr.PublicKey{P:publicKey, R:R}
-		pubKeys = append(pubKeys, pubKey)
+	for i, publicKey := range publicKeys {
+		pubKeys = append(pubKeys, &schnorr.PublicKey{P:publicKey, R:publicNonces[i]})
 	}
+	return pubKeys, nil
+}
 
-	return schnorr.VerifySignInput(signedPubKeys, pubKeys, message, signInput)
+//VerifySubgroup 验证签名由 keySet 中 bitmap 标记的成员共同生成
+//签名时使用 schnorr.WithSubgroup(keySet, bitmap)，publicKeys 为 bitmap 标记的成员
+func VerifySubgroup(keySet [][33]byte, bitmap []byte, message []byte, signature [64]byte) (bool, error) {
+	return schnorr.VerifySubgroup(keySet, bitmap, message, signature)
 }
@@ -0,0 +1,123 @@
+package multisign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+// oldOffset 是旧方案 GetPrivateK0 / GetPublicR 使用的偏移 h = hmac512(Px, Py||msg)[:32]
+// 只依赖公钥，任何人都能算出来
+func oldOffset(publicKey [33]byte, message []byte) *big.Int {
+	Px, Py := schnorr.Unmarshal(schnorr.Curve, publicKey[:])
+	hmac512 := hmac.New(sha512.New, schnorr.IntToByte(Px))
+	hmac512.Write(schnorr.IntToByte(Py))
+	hmac512.Write(message)
+	return new(big.Int).SetBytes(hmac512.Sum(nil)[:32])
+}
+
+// recoverKey 用旧方案的漏洞尝试从单个签名里解出私钥
+// k0 = d + h 时，s = ±(d + h) + e*d，分别按两种符号求解 d
+func recoverKey(publicKey [33]byte, message []byte, signature [64]byte) (*big.Int, bool) {
+	N := schnorr.Curve.N
+	h := oldOffset(publicKey, message)
+	s := new(big.Int).SetBytes(signature[32:])
+	eHash := sha256.Sum256(append(append(append([]byte{}, signature[:32]...), publicKey[:]...), message...))
+	e := new(big.Int).SetBytes(eHash[:])
+	e.Mod(e, N)
+
+	Px, _ := schnorr.Unmarshal(schnorr.Curve, publicKey[:])
+	candidates := []*big.Int{
+		// s = d + h + e*d  => d = (s - h) / (1 + e)
+		new(big.Int).Mul(new(big.Int).Sub(s, h), new(big.Int).ModInverse(new(big.Int).Add(e, schnorr.One), N)),
+		// s = -(d + h) + e*d  => d = (s + h) / (e - 1)
+		new(big.Int).Mul(new(big.Int).Add(s, h), new(big.Int).ModInverse(new(big.Int).Sub(e, schnorr.One), N)),
+	}
+	for _, d := range candidates {
+		d.Mod(d, N)
+		x, _ := schnorr.Curve.ScalarBaseMult(schnorr.IntToByte(d))
+		if x.Cmp(Px) == 0 {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func TestSignatureDoesNotLeakKey(t *testing.T) {
+	message := []byte("test msg")
+	privateKey, publicKey := schnorr.GenKey()
+
+	// 旧方案: k0 = d + h, 单个签名就能解出私钥
+	d := new(big.Int).SetBytes(privateKey[:])
+	oldK0 := new(big.Int).Add(d, oldOffset(publicKey, message))
+	oldK0.Mod(oldK0, schnorr.Curve.N)
+	var oldK032 [32]byte
+	copy(oldK032[:], schnorr.IntToByte(oldK0))
+	Rx, Ry := schnorr.Curve.ScalarBaseMult(oldK032[:])
+	var oldR [33]byte
+	copy(oldR[:], schnorr.Marshal(schnorr.Curve, Rx, Ry))
+	oldSign, err := Sign(message, privateKey, oldK032, [][33]byte{publicKey}, [][33]byte{oldR})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered, ok := recoverKey(publicKey, message, oldSign); !ok || recovered.Cmp(d) != 0 {
+		t.Fatal("attack should recover the key from the old nonce scheme")
+	}
+
+	// 新方案: Sign 和 AppendSignature 的结果都不能解出私钥
+	k0, R, err := GenNonce(privateKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, err := Sign(message, privateKey, k0, [][33]byte{publicKey}, [][33]byte{R})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := MultiVerify([][33]byte{publicKey}, message, sign); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+	if _, ok := recoverKey(publicKey, message, sign); ok {
+		t.Fatal("signature leaks the private key")
+	}
+
+	k0, R, err = GenNonce(privateKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, err = AppendSignature([64]byte{}, message, privateKey, k0, [][33]byte{publicKey}, [][33]byte{R}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := recoverKey(publicKey, message, sign); ok {
+		t.Fatal("signature leaks the private key")
+	}
+}
+
+func TestGenNonceIsFresh(t *testing.T) {
+	message := []byte("test msg")
+	privateKey, _ := schnorr.GenKey()
+
+	k1, R1, err := GenNonce(privateKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, R2, err := GenNonce(privateKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 == k2 || R1 == R2 {
+		t.Fatal("nonce must not repeat for the same key and message")
+	}
+}
+
+func TestVerifySignInputSizeMismatch(t *testing.T) {
+	_, P := schnorr.GenKey()
+	keys := [][33]byte{P, P}
+	if _, err := VerifySignInput(keys, keys, keys[:1], keys[:1], []byte("test msg"), [64]byte{}); !errors.Is(err, schnorr.ErrSizeMismatch) {
+		t.Fatal("more signed keys than publicKeys should fail", err)
+	}
+}
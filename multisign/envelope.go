@@ -0,0 +1,244 @@
+package multisign
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+	"sort"
+)
+
+// EnvelopeVersion 当前的信封格式版本
+const EnvelopeVersion = 1
+
+const (
+	envelopeKeysTag    = "schnorr-go/envelope-keys"
+	envelopeMessageTag = "schnorr-go/envelope-msg"
+	// envelopeMaxSigners signed 的长度和其中的序号都用2字节编码，序号最大为 0xFFFE
+	envelopeMaxSigners = 0xFFFF
+)
+
+// Envelope 参与者之间传递的签名中间结果
+// 除了 (Rx, s) 之外还带上公钥集合和消息的摘要、已经签过的参与者，
+// 收到后先用 Check 检查消息和公钥是否一致，再做曲线运算
+type Envelope struct {
+	Version       uint8
+	KeySetHash    [32]byte
+	MessageDigest [32]byte
+	// Signed 已经签过的参与者在 publicKeys 中的序号，从小到大排列
+	Signed    []int
+	Signature [64]byte
+}
+
+// NewEnvelope 创建还没有人签名的信封
+func NewEnvelope(message []byte, publicKeys [][33]byte) (*Envelope, error) {
+	if len(publicKeys) == 0 || len(publicKeys) > envelopeMaxSigners {
+		return nil, fmt.Errorf("%w: invalid publicKeys", schnorr.ErrInvalidLength)
+	}
+	return &Envelope{
+		Version:       EnvelopeVersion,
+		KeySetHash:    keySetHash(publicKeys),
+		MessageDigest: schnorr.TaggedHash(envelopeMessageTag, message),
+	}, nil
+}
+
+// Check 检查信封是否属于这个消息和公钥集合
+func (e *Envelope) Check(message []byte, publicKeys [][33]byte) error {
+	if e.Version != EnvelopeVersion {
+		return fmt.Errorf("%w: envelope version", ErrUnsupportedVersion)
+	}
+	if e.MessageDigest != schnorr.TaggedHash(envelopeMessageTag, message) {
+		return fmt.Errorf("%w: message does not match envelope", ErrEnvelopeMismatch)
+	}
+	if e.KeySetHash != keySetHash(publicKeys) {
+		return fmt.Errorf("%w: publicKeys do not match envelope", ErrEnvelopeMismatch)
+	}
+	return checkSigned(e.Signed, len(publicKeys))
+}
+
+// Complete 所有参与者是否都已经签名
+func (e *Envelope) Complete(publicKeys [][33]byte) bool {
+	return len(e.Signed) == len(publicKeys)
+}
+
+// AppendSignatureEnvelope 在信封上追加自己的签名，返回新的信封，参与者可以按任意顺序签名
+func AppendSignatureEnvelope(envelope *Envelope, message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, publicNonces [][33]byte, opts ...schnorr.Option) (*Envelope, error) {
+	if err := envelope.Check(message, publicKeys); err != nil {
+		return nil, err
+	}
+	signature, signed, err := AppendSignatureSigned(envelope.Signature, message, privateKey, k0, publicKeys, publicNonces, envelope.Signed, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Ints(signed)
+	return &Envelope{
+		Version:       EnvelopeVersion,
+		KeySetHash:    envelope.KeySetHash,
+		MessageDigest: envelope.MessageDigest,
+		Signed:        signed,
+		Signature:     signature,
+	}, nil
+}
+
+// VerifyEnvelope 验证信封中的中间结果，所有人都签完时等同于 MultiVerify
+func VerifyEnvelope(envelope *Envelope, message []byte, publicKeys [][33]byte, publicNonces [][33]byte, opts ...schnorr.Option) (bool, error) {
+	if err := envelope.Check(message, publicKeys); err != nil {
+		return false, err
+	}
+	if envelope.Complete(publicKeys) {
+		return MultiVerify(publicKeys, message, envelope.Signature, opts...)
+	}
+	return VerifySignInputSigned(envelope.Signed, publicKeys, publicNonces, message, envelope.Signature, opts...)
+}
+
+// MarshalBinary version(1) || KeySetHash(32) || MessageDigest(32) || len(Signed)(2) || Signed(2*len) || Rx(32) || s(32)
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if err := checkSigned(e.Signed, envelopeMaxSigners); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 1+32+32+2+2*len(e.Signed)+64)
+	buf = append(buf, e.Version)
+	buf = append(buf, e.KeySetHash[:]...)
+	buf = append(buf, e.MessageDigest[:]...)
+	buf = appendUint16(buf, len(e.Signed))
+	for _, i := range e.Signed {
+		buf = appendUint16(buf, i)
+	}
+	return append(buf, e.Signature[:]...), nil
+}
+
+// UnmarshalBinary 严格解析，长度、版本不对或者序号没有从小到大排列时返回错误
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	const fixed = 1 + 32 + 32 + 2 + 64
+	if len(data) < fixed {
+		return fmt.Errorf("%w: invalid envelope length", schnorr.ErrInvalidLength)
+	}
+	if data[0] != EnvelopeVersion {
+		return fmt.Errorf("%w: envelope version", ErrUnsupportedVersion)
+	}
+	n := int(binary.BigEndian.Uint16(data[65:67]))
+	if len(data) != fixed+2*n {
+		return fmt.Errorf("%w: invalid envelope length", schnorr.ErrInvalidLength)
+	}
+	ret := Envelope{Version: data[0]}
+	copy(ret.KeySetHash[:], data[1:33])
+	copy(ret.MessageDigest[:], data[33:65])
+	for i := 0; i < n; i++ {
+		ret.Signed = append(ret.Signed, int(binary.BigEndian.Uint16(data[67+2*i:])))
+	}
+	copy(ret.Signature[:], data[67+2*n:])
+	if err := checkSigned(ret.Signed, envelopeMaxSigners); err != nil {
+		return err
+	}
+	*e = ret
+	return nil
+}
+
+// envelopeJSON JSON 格式，字节数组使用 hex 编码
+type envelopeJSON struct {
+	Version       uint8  `json:"version"`
+	KeySetHash    string `json:"key_set_hash"`
+	MessageDigest string `json:"message_digest"`
+	Signed        []int  `json:"signed"`
+	R             string `json:"r"`
+	S             string `json:"s"`
+}
+
+// MarshalJSON 转换成 JSON
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	if err := checkSigned(e.Signed, envelopeMaxSigners); err != nil {
+		return nil, err
+	}
+	signed := e.Signed
+	if signed == nil {
+		signed = []int{}
+	}
+	return json.Marshal(&envelopeJSON{
+		Version:       e.Version,
+		KeySetHash:    hex.EncodeToString(e.KeySetHash[:]),
+		MessageDigest: hex.EncodeToString(e.MessageDigest[:]),
+		Signed:        signed,
+		R:             hex.EncodeToString(e.Signature[:32]),
+		S:             hex.EncodeToString(e.Signature[32:]),
+	})
+}
+
+// UnmarshalJSON 严格解析，不允许未知字段，所有字段都必须存在且长度正确
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Version       *uint8  `json:"version"`
+		KeySetHash    *string `json:"key_set_hash"`
+		MessageDigest *string `json:"message_digest"`
+		Signed        *[]int  `json:"signed"`
+		R             *string `json:"r"`
+		S             *string `json:"s"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("%w: trailing data", ErrInvalidEncoding)
+	}
+	if v.Version == nil || v.KeySetHash == nil || v.MessageDigest == nil || v.Signed == nil || v.R == nil || v.S == nil {
+		return fmt.Errorf("%w: missing envelope field", ErrInvalidEncoding)
+	}
+	if *v.Version != EnvelopeVersion {
+		return fmt.Errorf("%w: envelope version", ErrUnsupportedVersion)
+	}
+	ret := Envelope{Version: *v.Version, Signed: *v.Signed}
+	for _, f := range []struct {
+		dst []byte
+		src string
+	}{
+		{ret.KeySetHash[:], *v.KeySetHash},
+		{ret.MessageDigest[:], *v.MessageDigest},
+		{ret.Signature[:32], *v.R},
+		{ret.Signature[32:], *v.S},
+	} {
+		b, err := hex.DecodeString(f.src)
+		if err != nil || len(b) != len(f.dst) {
+			return fmt.Errorf("%w: invalid envelope field", ErrInvalidEncoding)
+		}
+		copy(f.dst, b)
+	}
+	if len(ret.Signed) == 0 {
+		ret.Signed = nil
+	}
+	if err := checkSigned(ret.Signed, envelopeMaxSigners); err != nil {
+		return err
+	}
+	*e = ret
+	return nil
+}
+
+func appendUint16(buf []byte, v int) []byte {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	return append(buf, b[:]...)
+}
+
+// keySetHash 公钥集合的摘要 TaggedHash("schnorr-go/envelope-keys", P1||...||Pn)
+func keySetHash(publicKeys [][33]byte) [32]byte {
+	var buf []byte
+	for _, publicKey := range publicKeys {
+		buf = append(buf, publicKey[:]...)
+	}
+	return schnorr.TaggedHash(envelopeKeysTag, buf)
+}
+
+// checkSigned 序号必须严格从小到大排列且小于 n
+func checkSigned(signed []int, n int) error {
+	for j, i := range signed {
+		if i < 0 || i >= n {
+			return schnorr.ErrInvalidIndex
+		}
+		if j > 0 && signed[j-1] >= i {
+			return fmt.Errorf("%w: signed indices are not sorted", schnorr.ErrInvalidIndex)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package multisign
+
+import (
+	"encoding/json"
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"strings"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	message := []byte("test msg")
+	var privateKeys, k0s [][32]byte
+	var publicKeys, publicNonces [][33]byte
+	for i := 0; i < 3; i++ {
+		d, P := schnorr.GenKey()
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKeys = append(privateKeys, d)
+		k0s = append(k0s, k0)
+		publicKeys = append(publicKeys, P)
+		publicNonces = append(publicNonces, R)
+	}
+
+	envelope, err := NewEnvelope(message, publicKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{1, 2, 0} {
+		// 每一步都经过一次序列化，模拟在参与者之间传递
+		data, err := envelope.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var received Envelope
+		if err := received.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if ret, err := VerifyEnvelope(&received, message, publicKeys, publicNonces); err != nil || !ret {
+			t.Fatal("envelope verification failed", err)
+		}
+		envelope, err = AppendSignatureEnvelope(&received, message, privateKeys[i], k0s[i], publicKeys, publicNonces)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !envelope.Complete(publicKeys) {
+		t.Fatal("envelope should be complete")
+	}
+	if ret, err := MultiVerify(publicKeys, message, envelope.Signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Envelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := VerifyEnvelope(&decoded, message, publicKeys, publicNonces); err != nil || !ret {
+		t.Fatal("decoded envelope verification failed", err)
+	}
+
+	// 消息或公钥不一致时在曲线运算之前报错
+	if err := decoded.Check([]byte("other msg"), publicKeys); !errors.Is(err, ErrEnvelopeMismatch) || !strings.Contains(err.Error(), "message") {
+		t.Fatal("mismatched message should be detected", err)
+	}
+	if err := decoded.Check(message, [][33]byte{publicKeys[1], publicKeys[0], publicKeys[2]}); !errors.Is(err, ErrEnvelopeMismatch) || !strings.Contains(err.Error(), "publicKeys") {
+		t.Fatal("mismatched publicKeys should be detected", err)
+	}
+}
+
+func TestEnvelopeStrictParsing(t *testing.T) {
+	envelope, err := NewEnvelope([]byte("test msg"), [][33]byte{{2}, {3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.Signed = []int{0, 1}
+	data, err := envelope.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Envelope
+	if err := decoded.UnmarshalBinary(append(data, 0)); !errors.Is(err, schnorr.ErrInvalidLength) {
+		t.Fatal("trailing data should be rejected")
+	}
+	bad := append([]byte{}, data...)
+	bad[0] = 2
+	if err := decoded.UnmarshalBinary(bad); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatal("unknown version should be rejected")
+	}
+	// 序号没有从小到大排列
+	bad = append([]byte{}, data...)
+	bad[68], bad[70] = 1, 0
+	if err := decoded.UnmarshalBinary(bad); !errors.Is(err, schnorr.ErrInvalidIndex) {
+		t.Fatal("unsorted indices should be rejected")
+	}
+
+	js, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		strings.Replace(string(js), `"version":1`, `"version":1,"extra":1`, 1),
+		strings.Replace(string(js), `"signed":[0,1],`, ``, 1),
+		strings.Replace(string(js), `"r":"`, `"r":"00`, 1),
+		string(js) + `{}`,
+	} {
+		if err := decoded.UnmarshalJSON([]byte(s)); !errors.Is(err, ErrInvalidEncoding) {
+			t.Fatal("invalid json should be rejected", s)
+		}
+	}
+}
+
+// 所有人都签过的信封编码后仍然能解析
+func TestEnvelopeMaxSigners(t *testing.T) {
+	message := []byte("test msg")
+	publicKeys := make([][33]byte, envelopeMaxSigners+1)
+	if _, err := NewEnvelope(message, publicKeys); !errors.Is(err, schnorr.ErrInvalidLength) {
+		t.Fatal("too many publicKeys should be rejected")
+	}
+	publicKeys = publicKeys[:envelopeMaxSigners]
+	envelope, err := NewEnvelope(message, publicKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range publicKeys {
+		envelope.Signed = append(envelope.Signed, i)
+	}
+	if err := envelope.Check(message, publicKeys); err != nil {
+		t.Fatal(err)
+	}
+	data, err := envelope.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Envelope
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Signed) != envelopeMaxSigners || decoded.Signed[envelopeMaxSigners-1] != envelopeMaxSigners-1 {
+		t.Fatal("decoded signed indices mismatch")
+	}
+
+	envelope.Signed = append(envelope.Signed, envelopeMaxSigners)
+	if _, err := envelope.MarshalBinary(); !errors.Is(err, schnorr.ErrInvalidIndex) {
+		t.Fatal("index out of range should be rejected")
+	}
+}
@@ -0,0 +1,23 @@
+package multisign
+
+import "errors"
+
+// 包内的错误由下面的错误或者 schnorr 包的错误包装而来，调用方用 errors.Is 判断错误类型
+var (
+	// ErrUnsupportedVersion 信封或者签名记录的版本号不支持
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	// ErrInvalidEncoding 信封的 JSON 编码不合法，字段缺失或者格式不对
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	// ErrEnvelopeMismatch 信封和消息或者公钥集合对不上
+	ErrEnvelopeMismatch = errors.New("envelope mismatch")
+	// ErrDuplicateKey 公钥集合中有重复的公钥
+	ErrDuplicateKey = errors.New("duplicate public key")
+	// ErrCommitmentConflict 同一个参与者发来了不同的承诺
+	ErrCommitmentConflict = errors.New("commitment already received")
+	// ErrIncomplete 承诺或者随机数还没有收齐
+	ErrIncomplete = errors.New("session is not complete")
+	// ErrNonceMismatch 公开的随机数和承诺或者签名结果对不上
+	ErrNonceMismatch = errors.New("nonce mismatch")
+	// ErrNonceReused 会话的随机数已经用于签名
+	ErrNonceReused = errors.New("nonce already used")
+)
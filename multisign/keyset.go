@@ -0,0 +1,77 @@
+package multisign
+
+import (
+	"schnorr/schnorr-go/schnorr"
+)
+
+// KeySet 固定的一组签名公钥
+// 公钥只在创建时解析、检查一次，聚合公钥和聚合系数缓存下来，参与者不变时每次签名只需要处理 publicNonces
+// 创建后只读，可以被多个 goroutine 同时使用
+type KeySet struct {
+	ctx *schnorr.KeyAggContext
+}
+
+// NewKeySet 由所有参与签名的公钥创建 KeySet，opts 在之后所有的签名和验签中使用
+func NewKeySet(publicKeys [][33]byte, opts ...schnorr.Option) (*KeySet, error) {
+	ctx, err := schnorr.NewKeyAggContext(publicKeys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{ctx: ctx}, nil
+}
+
+// PublicKey 聚合公钥，使用了 schnorr.WithTweak 时为调整后的公钥
+func (ks *KeySet) PublicKey() [33]byte {
+	return ks.ctx.PublicKey()
+}
+
+// InternalKey 调整之前的聚合公钥，创建时使用了 schnorr.WithTweak 时可以作为 Taproot 内部公钥
+func (ks *KeySet) InternalKey() [33]byte {
+	return ks.ctx.InternalKey()
+}
+
+// AggregateKey 没有任何调整的聚合公钥，用 hdkey.NewAggregateKey 生成委员会的扩展公钥
+// 派生出的子公钥用 hdkey.DeriveTweak 得到的调整值和 schnorr.WithPlainTweak 创建 KeySet 签名
+func (ks *KeySet) AggregateKey() [33]byte {
+	return ks.ctx.AggregateKey()
+}
+
+// PublicKeys 所有参与签名的公钥
+func (ks *KeySet) PublicKeys() [][33]byte {
+	return ks.ctx.PublicKeys()
+}
+
+// Sign 同 multisign.Sign，publicNonces 和创建时的公钥一一对应
+func (ks *KeySet) Sign(message []byte, privateKey [32]byte, k0 [32]byte, publicNonces [][33]byte) (signOutput [64]byte, err error) {
+	privKey := &schnorr.PrivateKey{D: privateKey, K0: k0}
+	Rix, _, s, err := ks.ctx.Sign(message, privKey, publicNonces)
+	if err != nil {
+		return signOutput, err
+	}
+	copy(signOutput[:32], schnorr.IntToByte(Rix))
+	copy(signOutput[32:], schnorr.IntToByte(s))
+	return signOutput, nil
+}
+
+// AppendSignature 同 multisign.AppendSignature，序号小于 index 的参与者已经签完
+func (ks *KeySet) AppendSignature(signInput [64]byte, message []byte, privateKey [32]byte, k0 [32]byte, publicNonces [][33]byte, index int) (signOutput [64]byte, err error) {
+	if index < 0 || index >= len(publicNonces) {
+		return signOutput, schnorr.ErrInvalidIndex
+	}
+	signed := make([]int, index)
+	for i := range signed {
+		signed[i] = i
+	}
+	privKey := &schnorr.PrivateKey{D: privateKey, K0: k0}
+	return ks.ctx.AppendSignature(signInput, message, privKey, publicNonces, signed)
+}
+
+// VerifySignInput 验证 signed 中的参与者签名的中间结果，按顺序签名时 signed 为 0, 1, ..., index-1
+func (ks *KeySet) VerifySignInput(signed []int, publicNonces [][33]byte, message []byte, signInput [64]byte) (bool, error) {
+	return ks.ctx.VerifySignInput(signed, publicNonces, message, signInput)
+}
+
+// MultiVerify 验证聚合签名，同 multisign.MultiVerify
+func (ks *KeySet) MultiVerify(message []byte, signature [64]byte) (bool, error) {
+	return ks.ctx.MultiVerify(message, signature)
+}
@@ -0,0 +1,275 @@
+package multisign
+
+import (
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"sync"
+	"testing"
+)
+
+type testSigners struct {
+	privateKeys, k0s         [][32]byte
+	publicKeys, publicNonces [][33]byte
+}
+
+func newTestSigners(tb testing.TB, n int, message []byte) *testSigners {
+	s := &testSigners{}
+	for i := 0; i < n; i++ {
+		d, P := schnorr.GenKey()
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		s.privateKeys = append(s.privateKeys, d)
+		s.k0s = append(s.k0s, k0)
+		s.publicKeys = append(s.publicKeys, P)
+		s.publicNonces = append(s.publicNonces, R)
+	}
+	return s
+}
+
+func TestKeySet(t *testing.T) {
+	message := []byte("test msg")
+	s := newTestSigners(t, 4, message)
+	opt := schnorr.WithAggregation(schnorr.AggregationHardened)
+	ks, err := NewKeySet(s.publicKeys, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signature [64]byte
+	var signed []int
+	for i := range s.publicKeys {
+		if ret, err := ks.VerifySignInput(signed, s.publicNonces, message, signature); err != nil || !ret {
+			t.Fatal("sign input verification failed", err)
+		}
+		// 和不使用 KeySet 的结果相同
+		expected, err := AppendSignature(signature, message, s.privateKeys[i], s.k0s[i], s.publicKeys, s.publicNonces, i, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err = ks.AppendSignature(signature, message, s.privateKeys[i], s.k0s[i], s.publicNonces, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signature != expected {
+			t.Fatal("KeySet.AppendSignature mismatch")
+		}
+		signed = append(signed, i)
+	}
+	if ret, err := ks.MultiVerify(message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+	if ret, err := MultiVerify(s.publicKeys, message, signature, opt); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+	if ret, _ := ks.MultiVerify([]byte("other msg"), signature); ret {
+		t.Fatal("wrong message should fail")
+	}
+
+	partial, err := ks.Sign(message, s.privateKeys[1], s.k0s[1], s.publicNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Sign(message, s.privateKeys[1], s.k0s[1], s.publicKeys, s.publicNonces, opt)
+	if err != nil || partial != expected {
+		t.Fatal("KeySet.Sign mismatch")
+	}
+}
+
+func TestKeySetInvalid(t *testing.T) {
+	message := []byte("test msg")
+	s := newTestSigners(t, 3, message)
+	if _, err := NewKeySet(nil); !errors.Is(err, schnorr.ErrInvalidLength) {
+		t.Fatal("empty keys should fail")
+	}
+	if _, err := NewKeySet([][33]byte{{2}}); !errors.Is(err, schnorr.ErrInvalidPoint) {
+		t.Fatal("invalid key should fail")
+	}
+	ks, err := NewKeySet(s.publicKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Sign(message, s.privateKeys[0], s.k0s[0], s.publicNonces[:2]); !errors.Is(err, schnorr.ErrSizeMismatch) {
+		t.Fatal("nonces size mismatch should fail")
+	}
+	d, _ := schnorr.GenKey()
+	if _, err := ks.Sign(message, d, s.k0s[0], s.publicNonces); !errors.Is(err, schnorr.ErrKeyNotInSet) {
+		t.Fatal("unknown key should fail")
+	}
+	if _, err := ks.AppendSignature([64]byte{}, message, s.privateKeys[0], s.k0s[0], s.publicNonces, 3); !errors.Is(err, schnorr.ErrInvalidIndex) {
+		t.Fatal("invalid index should fail")
+	}
+	partial, err := ks.AppendSignature([64]byte{}, message, s.privateKeys[0], s.k0s[0], s.publicNonces, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.AppendSignature(partial, message, s.privateKeys[0], s.k0s[0], s.publicNonces, 1); !errors.Is(err, schnorr.ErrAlreadySigned) {
+		t.Fatal("signing twice should fail")
+	}
+	if ret, _ := ks.VerifySignInput([]int{1}, s.publicNonces, message, partial); ret {
+		t.Fatal("wrong signed indices should fail")
+	}
+	if _, err := ks.VerifySignInput([]int{0, 0}, s.publicNonces, message, partial); !errors.Is(err, schnorr.ErrDuplicateIndex) {
+		t.Fatal("duplicate indices should fail")
+	}
+}
+
+func TestKeySetTweak(t *testing.T) {
+	message := []byte("test msg")
+	data := []byte("script tree")
+	s := newTestSigners(t, 3, message)
+	opts := []schnorr.Option{schnorr.WithAggregation(schnorr.AggregationHardened), schnorr.WithTweak(data)}
+	ks, err := NewKeySet(s.publicKeys, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Q, err := schnorr.TweakPublicKey(ks.InternalKey(), data)
+	if err != nil || Q != ks.PublicKey() {
+		t.Fatal("tweaked key mismatch")
+	}
+	transcript, err := NewTranscript(message, s.publicKeys, s.publicNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signature [64]byte
+	for i := range s.publicKeys {
+		if signature, err = ks.AppendSignature(signature, message, s.privateKeys[i], s.k0s[i], s.publicNonces, i); err != nil {
+			t.Fatal(err)
+		}
+		if err := transcript.Append(s.privateKeys[i], s.k0s[i], opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if transcript.Signature() != signature {
+		t.Fatal("transcript signature mismatch")
+	}
+	if err := Diagnose(transcript, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := Verify(Q, message, signature); err != nil || !ret {
+		t.Fatal("signature is not valid under the tweaked key", err)
+	}
+}
+
+func TestKeySetConcurrent(t *testing.T) {
+	signers := newTestSigners(t, 5, nil)
+	ks, err := NewKeySet(signers.publicKeys, schnorr.WithAggregation(schnorr.AggregationHardened))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一个 KeySet 同时签多个消息，每个消息使用新的随机数
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(message []byte) {
+			defer wg.Done()
+			k0s := make([][32]byte, len(signers.privateKeys))
+			publicNonces := make([][33]byte, len(signers.privateKeys))
+			for i, d := range signers.privateKeys {
+				var err error
+				if k0s[i], publicNonces[i], err = GenNonce(d, message); err != nil {
+					errs <- err
+					return
+				}
+			}
+			var signature [64]byte
+			for i, d := range signers.privateKeys {
+				var err error
+				if signature, err = ks.AppendSignature(signature, message, d, k0s[i], publicNonces, i); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if ret, err := ks.MultiVerify(message, signature); err != nil || !ret {
+				errs <- errors.New("signature verification failed")
+			}
+		}([]byte{byte(g)})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func benchmarkSigners(b *testing.B, n int) (*testSigners, []byte, schnorr.Option) {
+	message := []byte("benchmark")
+	return newTestSigners(b, n, message), message, schnorr.WithAggregation(schnorr.AggregationHardened)
+}
+
+func BenchmarkSign1000(b *testing.B) {
+	s, message, opt := benchmarkSigners(b, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Sign(message, s.privateKeys[0], s.k0s[0], s.publicKeys, s.publicNonces, opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeySetSign1000(b *testing.B) {
+	s, message, opt := benchmarkSigners(b, 1000)
+	ks, err := NewKeySet(s.publicKeys, opt)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ks.Sign(message, s.privateKeys[0], s.k0s[0], s.publicNonces); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 前500人已经签名，验证中间结果
+func BenchmarkVerifySignInput1000(b *testing.B) {
+	s, message, opt := benchmarkSigners(b, 1000)
+	ks, _ := NewKeySet(s.publicKeys, opt)
+	signInput, signed := halfSigned(b, ks, s, message)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ret, err := VerifySignInputSigned(signed, s.publicKeys, s.publicNonces, message, signInput, opt); err != nil || !ret {
+			b.Fatal("verification failed", err)
+		}
+	}
+}
+
+func BenchmarkKeySetVerifySignInput1000(b *testing.B) {
+	s, message, opt := benchmarkSigners(b, 1000)
+	ks, _ := NewKeySet(s.publicKeys, opt)
+	signInput, signed := halfSigned(b, ks, s, message)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ret, err := ks.VerifySignInput(signed, s.publicNonces, message, signInput); err != nil || !ret {
+			b.Fatal("verification failed", err)
+		}
+	}
+}
+
+// halfSigned 前一半参与者的签名结果，R 和 s 分别相加
+func halfSigned(b *testing.B, ks *KeySet, s *testSigners, message []byte) ([64]byte, []int) {
+	var R schnorr.Point
+	var sum schnorr.Scalar
+	var signed []int
+	for i := 0; i < len(s.publicKeys)/2; i++ {
+		partial, err := ks.Sign(message, s.privateKeys[i], s.k0s[i], s.publicNonces)
+		if err != nil {
+			b.Fatal(err)
+		}
+		RI, err := new(schnorr.Point).SetBytes(s.publicNonces[i][:])
+		if err != nil {
+			b.Fatal(err)
+		}
+		var si [32]byte
+		copy(si[:], partial[32:])
+		sI, err := schnorr.NewScalar(si)
+		if err != nil {
+			b.Fatal(err)
+		}
+		R.Add(&R, RI)
+		sum.Add(&sum, sI)
+		signed = append(signed, i)
+	}
+	return schnorr.NewSignature(&R, &sum), signed
+}
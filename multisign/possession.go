@@ -0,0 +1,52 @@
+package multisign
+
+import (
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+)
+
+// ProvePossession 生成公钥的持有证明，登记公钥时和公钥一起提交
+func ProvePossession(privateKey [32]byte) ([64]byte, error) {
+	return schnorr.ProvePossession(privateKey)
+}
+
+// VerifyPossessions 检查每个公钥的持有证明，proofs 和 publicKeys 一一对应
+// 全部通过之后，这些公钥可以直接相加聚合(schnorr.AggregationSum)
+func VerifyPossessions(publicKeys [][33]byte, proofs [][64]byte) error {
+	if len(publicKeys) == 0 {
+		return fmt.Errorf("%w: invalid publicKeys", schnorr.ErrInvalidLength)
+	}
+	if len(publicKeys) != len(proofs) {
+		return fmt.Errorf("%w: proofs size is not equal to publicKeys", schnorr.ErrSizeMismatch)
+	}
+	for i, publicKey := range publicKeys {
+		if ret, err := schnorr.VerifyPossession(publicKey, proofs[i]); err != nil || !ret {
+			return fmt.Errorf("%w: proof of possession", schnorr.ErrVerificationFailed)
+		}
+	}
+	return nil
+}
+
+// AppendSignatureWithPossession 同 AppendSignature，签名前先检查所有公钥的持有证明
+func AppendSignatureWithPossession(signInput [64]byte, message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, proofs [][64]byte, publicNonces [][33]byte, index int) (signOutput [64]byte, err error) {
+	if err = VerifyPossessions(publicKeys, proofs); err != nil {
+		return signOutput, err
+	}
+	return AppendSignature(signInput, message, privateKey, k0, publicKeys, publicNonces, index)
+}
+
+// SignWithPossession 同 Sign，签名前先检查所有公钥的持有证明
+func SignWithPossession(message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, proofs [][64]byte, publicNonces [][33]byte) (signOutput [64]byte, err error) {
+	if err = VerifyPossessions(publicKeys, proofs); err != nil {
+		return signOutput, err
+	}
+	return Sign(message, privateKey, k0, publicKeys, publicNonces)
+}
+
+// MultiVerifyWithPossession 同 MultiVerify，验签前先检查所有公钥的持有证明
+func MultiVerifyWithPossession(publicKeys [][33]byte, proofs [][64]byte, message []byte, signature [64]byte) (bool, error) {
+	if err := VerifyPossessions(publicKeys, proofs); err != nil {
+		return false, err
+	}
+	return MultiVerify(publicKeys, message, signature)
+}
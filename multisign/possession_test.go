@@ -0,0 +1,89 @@
+package multisign
+
+import (
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func TestSignWithPossession(t *testing.T) {
+	message := []byte("test msg")
+	var privateKeys [][32]byte
+	var publicKeys [][33]byte
+	var proofs [][64]byte
+	for i := 0; i < 3; i++ {
+		d, P := schnorr.GenKey()
+		proof, err := ProvePossession(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKeys = append(privateKeys, d)
+		publicKeys = append(publicKeys, P)
+		proofs = append(proofs, proof)
+	}
+
+	var k0s [][32]byte
+	var publicNonces [][33]byte
+	for _, d := range privateKeys {
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k0s = append(k0s, k0)
+		publicNonces = append(publicNonces, R)
+	}
+
+	var signature [64]byte
+	var err error
+	for i, d := range privateKeys {
+		signature, err = AppendSignatureWithPossession(signature, message, d, k0s[i], publicKeys, proofs, publicNonces, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ret, err := MultiVerifyWithPossession(publicKeys, proofs, message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+}
+
+func TestRogueKeyWithoutPossession(t *testing.T) {
+	message := []byte("test msg")
+	d, honest := schnorr.GenKey()
+	proof, err := ProvePossession(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 攻击者登记 P' = X - P, 但是不知道 P' 对应的私钥，只能拿自己的私钥 x 伪造证明
+	x, X := schnorr.GenKey()
+	Xx, Xy := schnorr.Unmarshal(schnorr.Curve, X[:])
+	Hx, Hy := schnorr.Unmarshal(schnorr.Curve, honest[:])
+	rogueX, rogueY := schnorr.Curve.Add(Xx, Xy, Hx, new(big.Int).Sub(schnorr.Curve.P, Hy))
+	var rogue [33]byte
+	copy(rogue[:], schnorr.Marshal(schnorr.Curve, rogueX, rogueY))
+	rogueProof, err := ProvePossession(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKeys := [][33]byte{honest, rogue}
+	proofs := [][64]byte{proof, rogueProof}
+	if err := VerifyPossessions(publicKeys, proofs); err == nil {
+		t.Fatal("rogue key should be rejected")
+	}
+
+	k0, R, err := GenNonce(x, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := Sign(message, x, k0, [][33]byte{X}, [][33]byte{R})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, _ := MultiVerify(publicKeys, message, signature); !ret {
+		t.Fatal("attack should succeed without proofs of possession")
+	}
+	if ret, _ := MultiVerifyWithPossession(publicKeys, proofs, message, signature); ret {
+		t.Fatal("attack should fail with proofs of possession")
+	}
+}
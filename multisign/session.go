@@ -0,0 +1,213 @@
+package multisign
+
+import (
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+)
+
+const commitmentTag = "schnorr-go/commitment"
+
+// Session 一次多方签名的交互过程，每个参与者各自持有一个Session
+// 第一轮: 每个参与者生成新的随机数，广播对R的承诺 Commitment()
+// 第二轮: 收齐所有人的承诺后，广播自己的R Nonce()，收到别人的R时检查承诺 AddNonce()
+// 第三轮: 所有人的R都公开并检查通过后，生成部分签名 Sign() 或 AppendSignature()
+// 在收齐承诺之前公开R，或者在R没有全部公开之前签名，都可能被恶意参与者利用来伪造签名
+type Session struct {
+	message     []byte
+	privateKey  [32]byte
+	publicKeys  [][33]byte
+	index       int
+	k0          [32]byte
+	commitments []*[32]byte
+	nonces      []*[33]byte
+	used        bool
+	opts        []schnorr.Option
+}
+
+// NewSession 创建一次签名的会话，并生成本次使用的随机数
+// publicKeys 是所有参与者的公钥，所有参与者的顺序必须一致
+// opts 签名的可选配置，所有参与者必须一致
+func NewSession(message []byte, privateKey [32]byte, publicKeys [][33]byte, opts ...schnorr.Option) (*Session, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("%w: invalid publicKeys", schnorr.ErrInvalidLength)
+	}
+	Px, Py := schnorr.ScalarBaseMult(privateKey)
+	var P [33]byte
+	copy(P[:], schnorr.Marshal(schnorr.Curve, Px, Py))
+
+	index := -1
+	for i, publicKey := range publicKeys {
+		for j := 0; j < i; j++ {
+			if publicKeys[j] == publicKey {
+				return nil, ErrDuplicateKey
+			}
+		}
+		if publicKey == P {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, schnorr.ErrKeyNotInSet
+	}
+
+	k0, R, err := GenNonce(privateKey, message)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{
+		message:     append([]byte{}, message...),
+		privateKey:  privateKey,
+		publicKeys:  append([][33]byte{}, publicKeys...),
+		index:       index,
+		k0:          k0,
+		commitments: make([]*[32]byte, len(publicKeys)),
+		nonces:      make([]*[33]byte, len(publicKeys)),
+		opts:        opts,
+	}
+	commitment := s.commit(index, R)
+	s.commitments[index] = &commitment
+	s.nonces[index] = &R
+	return s, nil
+}
+
+// Index 自己在publicKeys中的序号
+func (s *Session) Index() int {
+	return s.index
+}
+
+// Commitment 第一轮，自己对R的承诺，需要广播给其他参与者
+func (s *Session) Commitment() [32]byte {
+	return *s.commitments[s.index]
+}
+
+// AddCommitment 第一轮，收到第index个参与者的承诺
+func (s *Session) AddCommitment(index int, commitment [32]byte) error {
+	if index >= len(s.publicKeys) || index < 0 {
+		return schnorr.ErrInvalidIndex
+	}
+	if s.commitments[index] != nil {
+		if *s.commitments[index] != commitment {
+			return ErrCommitmentConflict
+		}
+		return nil
+	}
+	s.commitments[index] = &commitment
+	return nil
+}
+
+// Nonce 第二轮，公开自己的R，必须在收齐所有人的承诺之后
+func (s *Session) Nonce() ([33]byte, error) {
+	for _, commitment := range s.commitments {
+		if commitment == nil {
+			return [33]byte{}, fmt.Errorf("%w: commitments are not complete", ErrIncomplete)
+		}
+	}
+	return *s.nonces[s.index], nil
+}
+
+// AddNonce 第二轮，收到第index个参与者公开的R，检查是否和承诺一致
+func (s *Session) AddNonce(index int, R [33]byte) error {
+	if index >= len(s.publicKeys) || index < 0 {
+		return schnorr.ErrInvalidIndex
+	}
+	if s.commitments[index] == nil {
+		return fmt.Errorf("%w: commitment not received", ErrIncomplete)
+	}
+	if Rx, _ := schnorr.Unmarshal(schnorr.Curve, R[:]); Rx == nil {
+		return fmt.Errorf("%w: invalid nonce", schnorr.ErrInvalidPoint)
+	}
+	if s.commit(index, R) != *s.commitments[index] {
+		return fmt.Errorf("%w: nonce does not match commitment", ErrNonceMismatch)
+	}
+	s.nonces[index] = &R
+	return nil
+}
+
+// PublicNonces 所有参与者的R，必须所有的R都已经公开
+func (s *Session) PublicNonces() ([][33]byte, error) {
+	var publicNonces [][33]byte
+	for _, R := range s.nonces {
+		if R == nil {
+			return nil, fmt.Errorf("%w: nonces are not complete", ErrIncomplete)
+		}
+		publicNonces = append(publicNonces, *R)
+	}
+	return publicNonces, nil
+}
+
+// Sign 第三轮，生成自己的部分签名，由某一个参与者调用Aggregate聚合
+// 随机数只能使用一次，Sign和AppendSignature只能调用其中一个，且只能调用一次
+func (s *Session) Sign() (signOutput [64]byte, err error) {
+	publicNonces, err := s.prepare()
+	if err != nil {
+		return signOutput, err
+	}
+	defer s.clear()
+	return Sign(s.message, s.privateKey, s.k0, s.publicKeys, publicNonces, s.opts...)
+}
+
+// AppendSignature 第三轮，按照publicKeys的顺序，在前一个参与者的签名上追加自己的签名
+func (s *Session) AppendSignature(signInput [64]byte) (signOutput [64]byte, err error) {
+	publicNonces, err := s.prepare()
+	if err != nil {
+		return signOutput, err
+	}
+	defer s.clear()
+	return AppendSignature(signInput, s.message, s.privateKey, s.k0, s.publicKeys, publicNonces, s.index, s.opts...)
+}
+
+// Aggregate 检查每个参与者的部分签名，并聚合成最终签名
+// partialSigs 和 publicKeys 一一对应
+func (s *Session) Aggregate(partialSigs [][64]byte) (signature [64]byte, err error) {
+	publicNonces, err := s.PublicNonces()
+	if err != nil {
+		return signature, err
+	}
+	if len(partialSigs) != len(s.publicKeys) {
+		return signature, fmt.Errorf("%w: partialSigs size is not equal to publicKeys", schnorr.ErrSizeMismatch)
+	}
+
+	var R schnorr.Point
+	var sum schnorr.Scalar
+	for i, partialSig := range partialSigs {
+		ret, err := VerifySignInput(s.publicKeys[i:i+1], publicNonces[i:i+1], s.publicKeys, publicNonces, s.message, partialSig, s.opts...)
+		if err != nil {
+			return signature, err
+		}
+		if !ret {
+			return signature, fmt.Errorf("%w: partial signature", schnorr.ErrVerificationFailed)
+		}
+		RI, err := new(schnorr.Point).SetBytes(publicNonces[i][:])
+		if err != nil {
+			return signature, err
+		}
+		var partial [32]byte
+		copy(partial[:], partialSig[32:])
+		sI, err := schnorr.NewScalar(partial)
+		if err != nil {
+			return signature, err
+		}
+		R.Add(&R, RI)
+		sum.Add(&sum, sI)
+	}
+	return schnorr.NewSignature(&R, &sum), nil
+}
+
+// commit 承诺 c = TaggedHash("schnorr-go/commitment", P||R||msg)
+func (s *Session) commit(index int, R [33]byte) [32]byte {
+	return schnorr.TaggedHash(commitmentTag, s.publicKeys[index][:], R[:], s.message)
+}
+
+// prepare 签名前检查所有承诺都已经打开，随机数没有用过
+func (s *Session) prepare() ([][33]byte, error) {
+	if s.used {
+		return nil, ErrNonceReused
+	}
+	return s.PublicNonces()
+}
+
+// clear 签名之后清除随机数，防止重复使用
+func (s *Session) clear() {
+	s.used = true
+	s.k0 = [32]byte{}
+}
@@ -0,0 +1,146 @@
+package multisign
+
+import (
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func newSessions(t *testing.T, message []byte, n int) ([]*Session, [][33]byte) {
+	var privateKeys [][32]byte
+	var publicKeys [][33]byte
+	for i := 0; i < n; i++ {
+		privateKey, publicKey := schnorr.GenKey()
+		privateKeys = append(privateKeys, privateKey)
+		publicKeys = append(publicKeys, publicKey)
+	}
+	var sessions []*Session
+	for _, privateKey := range privateKeys {
+		session, err := NewSession(message, privateKey, publicKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, publicKeys
+}
+
+// exchange 模拟两轮广播: 先交换承诺，再交换R
+func exchange(t *testing.T, sessions []*Session) {
+	for _, from := range sessions {
+		for _, to := range sessions {
+			if err := to.AddCommitment(from.Index(), from.Commitment()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, from := range sessions {
+		R, err := from.Nonce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, to := range sessions {
+			if err := to.AddNonce(from.Index(), R); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	message := []byte("test msg")
+	sessions, publicKeys := newSessions(t, message, 5)
+	exchange(t, sessions)
+
+	var partialSigs [][64]byte
+	for _, session := range sessions {
+		partialSig, err := session.Sign()
+		if err != nil {
+			t.Fatal(err)
+		}
+		partialSigs = append(partialSigs, partialSig)
+	}
+	sign, err := sessions[0].Aggregate(partialSigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := MultiVerify(publicKeys, message, sign); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+
+	if _, err := sessions[0].Sign(); !errors.Is(err, ErrNonceReused) {
+		t.Fatal("nonce must not be used twice")
+	}
+}
+
+func TestSessionAppendSignature(t *testing.T) {
+	message := []byte("test msg")
+	sessions, publicKeys := newSessions(t, message, 4)
+	exchange(t, sessions)
+
+	var sign [64]byte
+	var err error
+	for _, session := range sessions {
+		sign, err = session.AppendSignature(sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ret, err := MultiVerify(publicKeys, message, sign); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+}
+
+func TestSessionRefusesEarlySteps(t *testing.T) {
+	message := []byte("test msg")
+	sessions, _ := newSessions(t, message, 3)
+
+	// 没有收齐承诺，不能公开R
+	if _, err := sessions[0].Nonce(); !errors.Is(err, ErrIncomplete) {
+		t.Fatal("nonce revealed before all commitments are received")
+	}
+	// 没有收到承诺，不能接受R
+	R, _ := sessions[1].Nonce()
+	if err := sessions[0].AddNonce(1, R); !errors.Is(err, ErrIncomplete) {
+		t.Fatal("nonce accepted without commitment")
+	}
+
+	for _, from := range sessions {
+		for _, to := range sessions {
+			if err := to.AddCommitment(from.Index(), from.Commitment()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// 承诺不能被替换
+	if err := sessions[0].AddCommitment(1, [32]byte{1}); !errors.Is(err, ErrCommitmentConflict) {
+		t.Fatal("commitment replaced")
+	}
+
+	// R和承诺不一致
+	R2, err := sessions[2].Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions[0].AddNonce(1, R2); !errors.Is(err, ErrNonceMismatch) {
+		t.Fatal("nonce accepted with wrong commitment")
+	}
+
+	// 没有收齐R，不能签名
+	R1, err := sessions[1].Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions[0].AddNonce(1, R1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions[0].Sign(); !errors.Is(err, ErrIncomplete) {
+		t.Fatal("signed before all nonces are opened")
+	}
+	if err := sessions[0].AddNonce(2, R2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions[0].Sign(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+package multisign
+
+import (
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func TestSubgroup(t *testing.T) {
+	message := []byte("test msg")
+	var privateKeys [][32]byte
+	var keySet [][33]byte
+	for i := 0; i < 5; i++ {
+		d, P := schnorr.GenKey()
+		privateKeys = append(privateKeys, d)
+		keySet = append(keySet, P)
+	}
+
+	// 成员 0, 2, 3 签名，1 和 4 不参与
+	members := []int{0, 2, 3}
+	bitmap, err := schnorr.NewBitmap(len(keySet), members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeys, err := schnorr.SubgroupSigners(keySet, bitmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var k0s [][32]byte
+	var publicNonces [][33]byte
+	for _, i := range members {
+		k0, R, err := GenNonce(privateKeys[i], message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k0s = append(k0s, k0)
+		publicNonces = append(publicNonces, R)
+	}
+	opt := schnorr.WithSubgroup(keySet, bitmap)
+	var signature [64]byte
+	for j, i := range members {
+		signature, err = AppendSignature(signature, message, privateKeys[i], k0s[j], publicKeys, publicNonces, j, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := schnorr.MarshalSubgroupSignature(bitmap, signature)
+	parsedBitmap, parsedSignature, err := schnorr.ParseSubgroupSignature(len(keySet), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := VerifySubgroup(keySet, parsedBitmap, message, parsedSignature); err != nil || !ret {
+		t.Fatal("subgroup signature verification failed", err)
+	}
+	signers, err := schnorr.SubgroupSigners(keySet, parsedBitmap)
+	if err != nil || len(signers) != 3 || signers[1] != keySet[2] {
+		t.Fatal("signers mismatch")
+	}
+
+	// 声称其他成员也参与了签名
+	other, _ := schnorr.NewBitmap(len(keySet), 0, 1, 2, 3)
+	if ret, _ := VerifySubgroup(keySet, other, message, signature); ret {
+		t.Fatal("signature should not be valid for other bitmap")
+	}
+	// 同样的成员，但是登记的集合不同
+	if ret, _ := VerifySubgroup(append(keySet[:4:4], keySet[0]), bitmap, message, signature); ret {
+		t.Fatal("signature should not be valid for other key set")
+	}
+	// 不带 bitmap 的普通多签验证不通过
+	if ret, _ := MultiVerify(publicKeys, message, signature); ret {
+		t.Fatal("subgroup signature should not be a plain multisignature")
+	}
+	// 超出 keySet 的位
+	bad := append([]byte{}, bitmap...)
+	bad[0] |= 0x80
+	if _, err := schnorr.SubgroupSigners(keySet, bad); err == nil {
+		t.Fatal("bits outside key set should be rejected")
+	}
+}
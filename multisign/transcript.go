@@ -0,0 +1,204 @@
+package multisign
+
+import (
+	"encoding/binary"
+	"fmt"
+	"schnorr/schnorr-go/schnorr"
+)
+
+const transcriptVersion = 1
+
+// TranscriptStep 顺序签名中一个参与者的记录
+// Partial 是他自己的部分签名 (Rix, si)，Aggregate 是追加他的签名之后的中间结果
+type TranscriptStep struct {
+	Index     int
+	Partial   [64]byte
+	Aggregate [64]byte
+}
+
+// Transcript 顺序签名的完整记录，代替只传递 [64]byte 中间结果
+// 中间结果验证失败时，用 Diagnose 找出第一个出错的参与者
+type Transcript struct {
+	Message      []byte
+	PublicKeys   [][33]byte
+	PublicNonces [][33]byte
+	Steps        []TranscriptStep
+}
+
+// BlameError Diagnose 找到的出错的参与者
+type BlameError struct {
+	Index     int
+	PublicKey [33]byte
+	Reason    string
+}
+
+func (e *BlameError) Error() string {
+	return fmt.Sprintf("signer %d (%x) is at fault: %s", e.Index, e.PublicKey, e.Reason)
+}
+
+// NewTranscript 创建一次顺序签名的记录
+func NewTranscript(message []byte, publicKeys [][33]byte, publicNonces [][33]byte) (*Transcript, error) {
+	if _, err := toPublicKeys(publicKeys, publicNonces); err != nil {
+		return nil, err
+	}
+	return &Transcript{
+		Message:      append([]byte{}, message...),
+		PublicKeys:   append([][33]byte{}, publicKeys...),
+		PublicNonces: append([][33]byte{}, publicNonces...),
+	}, nil
+}
+
+// Signature 当前的中间结果，所有人都签完之后就是最终签名
+func (t *Transcript) Signature() [64]byte {
+	if len(t.Steps) == 0 {
+		return [64]byte{}
+	}
+	return t.Steps[len(t.Steps)-1].Aggregate
+}
+
+// Append 按照 publicKeys 的顺序追加下一个参与者的签名，同时记录他自己的部分签名
+// 前面的中间结果验证失败时返回错误，可以用 Diagnose 找出出错的参与者
+func (t *Transcript) Append(privateKey [32]byte, k0 [32]byte, opts ...schnorr.Option) error {
+	index := len(t.Steps)
+	aggregate, err := AppendSignature(t.Signature(), t.Message, privateKey, k0, t.PublicKeys, t.PublicNonces, index, opts...)
+	if err != nil {
+		return err
+	}
+	partial, err := Sign(t.Message, privateKey, k0, t.PublicKeys, t.PublicNonces, opts...)
+	if err != nil {
+		return err
+	}
+	t.Steps = append(t.Steps, TranscriptStep{Index: index, Partial: partial, Aggregate: aggregate})
+	return nil
+}
+
+// Diagnose 依次检查每一步，返回第一个出错的参与者 *BlameError，没有问题时返回 nil
+// 每一步检查: 部分签名对他自己的 P 和 R 有效; 中间结果等于上一步的中间结果加上他的部分签名
+// opts 必须和签名时一致
+func Diagnose(transcript *Transcript, opts ...schnorr.Option) error {
+	t := transcript
+	if _, err := toPublicKeys(t.PublicKeys, t.PublicNonces); err != nil {
+		return err
+	}
+	if len(t.Steps) > len(t.PublicKeys) {
+		return fmt.Errorf("%w: too many steps", schnorr.ErrInvalidLength)
+	}
+	var R schnorr.Point
+	var s schnorr.Scalar
+	for i, step := range t.Steps {
+		if step.Index != i {
+			return fmt.Errorf("%w: invalid step index", schnorr.ErrInvalidIndex)
+		}
+		blame := func(reason string) error {
+			return &BlameError{Index: i, PublicKey: t.PublicKeys[i], Reason: reason}
+		}
+		ret, err := VerifySignInput(t.PublicKeys[i:i+1], t.PublicNonces[i:i+1], t.PublicKeys, t.PublicNonces, t.Message, step.Partial, opts...)
+		if err != nil || !ret {
+			return blame("partial signature does not verify against own P and R")
+		}
+
+		RI, err := new(schnorr.Point).SetBytes(t.PublicNonces[i][:])
+		if err != nil {
+			return err
+		}
+		var partial [32]byte
+		copy(partial[:], step.Partial[32:])
+		sI, err := schnorr.NewScalar(partial)
+		if err != nil {
+			return err
+		}
+		R.Add(&R, RI)
+		s.Add(&s, sI)
+		if step.Aggregate != schnorr.NewSignature(&R, &s) {
+			return blame("aggregate is not previous aggregate plus own partial signature")
+		}
+	}
+	return nil
+}
+
+// MarshalBinary 序列化
+// version(1) || len(msg)(4) || msg || n(4) || (P||R)*n || steps(4) || (index(4)||partial||aggregate)*steps
+func (t *Transcript) MarshalBinary() ([]byte, error) {
+	if len(t.PublicKeys) != len(t.PublicNonces) {
+		return nil, fmt.Errorf("%w: publicNonces size is not equal to publicKeys", schnorr.ErrSizeMismatch)
+	}
+	buf := []byte{transcriptVersion}
+	buf = appendUint32(buf, len(t.Message))
+	buf = append(buf, t.Message...)
+	buf = appendUint32(buf, len(t.PublicKeys))
+	for i := range t.PublicKeys {
+		buf = append(buf, t.PublicKeys[i][:]...)
+		buf = append(buf, t.PublicNonces[i][:]...)
+	}
+	buf = appendUint32(buf, len(t.Steps))
+	for _, step := range t.Steps {
+		buf = appendUint32(buf, step.Index)
+		buf = append(buf, step.Partial[:]...)
+		buf = append(buf, step.Aggregate[:]...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary 解析 MarshalBinary 的结果
+func (t *Transcript) UnmarshalBinary(data []byte) error {
+	r := &reader{data: data}
+	if version := r.next(1); version == nil || version[0] != transcriptVersion {
+		return fmt.Errorf("%w: transcript version", ErrUnsupportedVersion)
+	}
+	var ret Transcript
+	ret.Message = append([]byte{}, r.next(r.uint32())...)
+	n := r.uint32()
+	for i := 0; i < n && r.err == nil; i++ {
+		var P, R [33]byte
+		copy(P[:], r.next(33))
+		copy(R[:], r.next(33))
+		ret.PublicKeys = append(ret.PublicKeys, P)
+		ret.PublicNonces = append(ret.PublicNonces, R)
+	}
+	steps := r.uint32()
+	for i := 0; i < steps && r.err == nil; i++ {
+		var step TranscriptStep
+		step.Index = r.uint32()
+		copy(step.Partial[:], r.next(64))
+		copy(step.Aggregate[:], r.next(64))
+		ret.Steps = append(ret.Steps, step)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: trailing data", schnorr.ErrInvalidLength)
+	}
+	*t = ret
+	return nil
+}
+
+func appendUint32(buf []byte, v int) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	return append(buf, b[:]...)
+}
+
+// reader 顺序读取，长度不够时记录错误
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", schnorr.ErrInvalidLength)
+		return nil
+	}
+	ret := r.data[:n]
+	r.data = r.data[n:]
+	return ret
+}
+
+func (r *reader) uint32() int {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(b))
+}
@@ -0,0 +1,94 @@
+package multisign
+
+import (
+	"errors"
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func newTestTranscript(t *testing.T, n int) (*Transcript, [][32]byte, [][32]byte) {
+	message := []byte("test msg")
+	var privateKeys, k0s [][32]byte
+	var publicKeys, publicNonces [][33]byte
+	for i := 0; i < n; i++ {
+		d, P := schnorr.GenKey()
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKeys = append(privateKeys, d)
+		k0s = append(k0s, k0)
+		publicKeys = append(publicKeys, P)
+		publicNonces = append(publicNonces, R)
+	}
+	transcript, err := NewTranscript(message, publicKeys, publicNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transcript, privateKeys, k0s
+}
+
+func TestTranscript(t *testing.T) {
+	transcript, privateKeys, k0s := newTestTranscript(t, 4)
+	for i := range privateKeys {
+		if err := transcript.Append(privateKeys[i], k0s[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Diagnose(transcript); err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := MultiVerify(transcript.PublicKeys, transcript.Message, transcript.Signature()); err != nil || !ret {
+		t.Fatal("signature verification failed")
+	}
+
+	data, err := transcript.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Transcript
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := Diagnose(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Signature() != transcript.Signature() {
+		t.Fatal("decoded transcript mismatch")
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, schnorr.ErrInvalidLength) {
+		t.Fatal("truncated transcript should be rejected")
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	// 第2个参与者(序号1)的部分签名错误，第3个参与者追加时失败
+	transcript, privateKeys, k0s := newTestTranscript(t, 4)
+	if err := transcript.Append(privateKeys[0], k0s[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := transcript.Append(privateKeys[1], k0s[1]); err != nil {
+		t.Fatal(err)
+	}
+	transcript.Steps[1].Partial[63] ^= 1
+	transcript.Steps[1].Aggregate[63] ^= 1
+	if err := transcript.Append(privateKeys[2], k0s[2]); err == nil {
+		t.Fatal("append on corrupted chain should fail")
+	}
+	var blame *BlameError
+	if err := Diagnose(transcript); !errors.As(err, &blame) || blame.Index != 1 || blame.PublicKey != transcript.PublicKeys[1] {
+		t.Fatal("expected blame on signer 1", err)
+	}
+
+	// 部分签名正确，但是转发的中间结果错误
+	transcript, privateKeys, k0s = newTestTranscript(t, 3)
+	for i := range privateKeys {
+		if err := transcript.Append(privateKeys[i], k0s[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transcript.Steps[2].Aggregate[0] ^= 1
+	if err := Diagnose(transcript); !errors.As(err, &blame) || blame.Index != 2 {
+		t.Fatal("expected blame on signer 2", err)
+	}
+}
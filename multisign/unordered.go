@@ -0,0 +1,50 @@
+package multisign
+
+import (
+	"schnorr/schnorr-go/schnorr"
+)
+
+// AppendSignatureSigned 和 AppendSignature 相同，但是参与者可以按任意顺序签名
+// signed 是已经签过的参与者在 publicKeys 中的序号，signInput 是他们的签名结果，第一个签名时为空
+// signedOutput 是 signed 加上自己的序号，和 signOutput 一起交给下一个参与者
+func AppendSignatureSigned(signInput [64]byte, message []byte, privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, publicNonces [][33]byte, signed []int, opts ...schnorr.Option) (signOutput [64]byte, signedOutput []int, err error) {
+	pubKeys, err := toPublicKeys(publicKeys, publicNonces)
+	if err != nil {
+		return signOutput, nil, err
+	}
+	index, err := ownIndex(privateKey, k0, publicKeys, publicNonces)
+	if err != nil {
+		return signOutput, nil, err
+	}
+	privKey := &schnorr.PrivateKey{D: privateKey, K0: k0}
+	signOutput, err = schnorr.AppendSignatureSigned(signInput, message, privKey, pubKeys, signed, opts...)
+	if err != nil {
+		return signOutput, nil, err
+	}
+	signedOutput = append(append([]int{}, signed...), index)
+	return signOutput, signedOutput, nil
+}
+
+// VerifySignInputSigned 验证 signed 中的参与者按任意顺序签名的中间结果
+func VerifySignInputSigned(signed []int, publicKeys [][33]byte, publicNonces [][33]byte, message []byte, signInput [64]byte, opts ...schnorr.Option) (bool, error) {
+	pubKeys, err := toPublicKeys(publicKeys, publicNonces)
+	if err != nil {
+		return false, err
+	}
+	return schnorr.VerifySignInputSigned(signed, pubKeys, message, signInput, opts...)
+}
+
+// ownIndex 私钥和随机数对应的 (P, R) 在 publicKeys 中的序号
+func ownIndex(privateKey [32]byte, k0 [32]byte, publicKeys [][33]byte, publicNonces [][33]byte) (int, error) {
+	Px, Py := schnorr.ScalarBaseMult(privateKey)
+	Rx, Ry := schnorr.ScalarBaseMult(k0)
+	var P, R [33]byte
+	copy(P[:], schnorr.Marshal(schnorr.Curve, Px, Py))
+	copy(R[:], schnorr.Marshal(schnorr.Curve, Rx, Ry))
+	for i := range publicKeys {
+		if publicKeys[i] == P && publicNonces[i] == R {
+			return i, nil
+		}
+	}
+	return -1, schnorr.ErrKeyNotInSet
+}
@@ -0,0 +1,59 @@
+package multisign
+
+import (
+	"schnorr/schnorr-go/schnorr"
+	"testing"
+)
+
+func TestAppendSignatureSigned(t *testing.T) {
+	message := []byte("test msg")
+	var privateKeys, k0s [][32]byte
+	var publicKeys, publicNonces [][33]byte
+	for i := 0; i < 4; i++ {
+		d, P := schnorr.GenKey()
+		k0, R, err := GenNonce(d, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKeys = append(privateKeys, d)
+		k0s = append(k0s, k0)
+		publicKeys = append(publicKeys, P)
+		publicNonces = append(publicNonces, R)
+	}
+
+	// 按 2, 0, 3, 1 的顺序签名
+	var signature [64]byte
+	var signed []int
+	var err error
+	for _, i := range []int{2, 0, 3, 1} {
+		if ret, err := VerifySignInputSigned(signed, publicKeys, publicNonces, message, signature); err != nil || !ret {
+			t.Fatal("sign input verification failed", signed, err)
+		}
+		signature, signed, err = AppendSignatureSigned(signature, message, privateKeys[i], k0s[i], publicKeys, publicNonces, signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(signed) != 4 || signed[0] != 2 || signed[3] != 1 {
+		t.Fatal("unexpected signed indices", signed)
+	}
+	if ret, err := MultiVerify(publicKeys, message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed", err)
+	}
+
+	// 已经签过的参与者不能再签
+	if _, _, err := AppendSignatureSigned(signature, message, privateKeys[0], k0s[0], publicKeys, publicNonces, []int{2, 0}); err == nil {
+		t.Fatal("signing twice should fail")
+	}
+	// signed 和中间结果不一致
+	partial, _, err := AppendSignatureSigned([64]byte{}, message, privateKeys[3], k0s[3], publicKeys, publicNonces, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AppendSignatureSigned(partial, message, privateKeys[1], k0s[1], publicKeys, publicNonces, []int{2}); err == nil {
+		t.Fatal("mismatched signed indices should fail")
+	}
+	if ret, _ := VerifySignInputSigned([]int{3, 3}, publicKeys, publicNonces, message, partial); ret {
+		t.Fatal("duplicate indices should fail")
+	}
+}
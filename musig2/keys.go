@@ -0,0 +1,226 @@
+// Package musig2 按照 BIP-327 实现 MuSig2 多签:
+// KeyAgg 用系数聚合公钥抵御 rogue key 攻击，每个签名者生成两个随机数，
+// 两轮交互(交换随机数、交换部分签名)得到一个 BIP-340 签名。
+package musig2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"sort"
+)
+
+const (
+	keyAggListTag  = "KeyAgg list"
+	keyAggCoeffTag = "KeyAgg coefficient"
+)
+
+// InvalidContributionError 某个参与者提供的数据不合法
+// Signer 是参与者的序号，-1 表示无法确定是谁，例如聚合后的随机数不合法
+// Contrib 是不合法的数据: "pubkey", "pubnonce", "aggnonce", "psig"
+type InvalidContributionError struct {
+	Signer  int
+	Contrib string
+}
+
+func (e *InvalidContributionError) Error() string {
+	if e.Signer < 0 {
+		return fmt.Sprintf("invalid %s", e.Contrib)
+	}
+	return fmt.Sprintf("signer %d provided invalid %s", e.Signer, e.Contrib)
+}
+
+// KeyAggContext KeyAgg 和 ApplyTweak 的结果
+// 创建之后不再修改，ApplyTweak 返回新的 KeyAggContext，可以在多个协程中使用
+type KeyAggContext struct {
+	pubkeys  [][33]byte
+	keysHash [32]byte
+	pk2      *[33]byte
+	qx, qy   *big.Int
+	gacc     *big.Int
+	tacc     *big.Int
+}
+
+// KeySort 按字典序排列公钥
+func KeySort(pubkeys [][33]byte) [][33]byte {
+	sorted := append([][33]byte{}, pubkeys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted
+}
+
+// KeyAgg 聚合公钥 Q = a1*P1 + a2*P2 + ... + au*Pu
+// ai = TaggedHash("KeyAgg coefficient", L||Pi), L = TaggedHash("KeyAgg list", P1||...||Pu)
+// 第二个不同的公钥系数固定为1
+func KeyAgg(pubkeys [][33]byte) (*KeyAggContext, error) {
+	if len(pubkeys) == 0 {
+		return nil, errors.New("invalid pubkeys")
+	}
+	ctx := &KeyAggContext{
+		pubkeys:  append([][33]byte{}, pubkeys...),
+		keysHash: hashKeys(pubkeys),
+		pk2:      secondKey(pubkeys),
+		gacc:     big.NewInt(1),
+		tacc:     big.NewInt(0),
+	}
+
+	qx, qy := schnorr.Zero, schnorr.Zero
+	for i, pk := range pubkeys {
+		Px, Py, err := cpoint(pk[:])
+		if err != nil {
+			return nil, &InvalidContributionError{Signer: i, Contrib: "pubkey"}
+		}
+		a := ctx.coefficient(pk)
+		aPx, aPy := schnorr.Curve.ScalarMult(Px, Py, schnorr.IntToByte(a))
+		qx, qy = schnorr.Curve.Add(qx, qy, aPx, aPy)
+	}
+	if isInfinity(qx, qy) {
+		return nil, errors.New("the result of key aggregation cannot be infinity")
+	}
+	ctx.qx, ctx.qy = qx, qy
+	return ctx, nil
+}
+
+// ApplyTweak 在聚合公钥上加 tweak，返回新的 KeyAggContext
+// isXonly 为 true 时按 x-only 公钥(偶数y)加 tweak，用于 taproot
+// Q' = g*Q + t*G, g = -1 当 isXonly 且 Q 的 y 是奇数，否则 g = 1
+func (ctx *KeyAggContext) ApplyTweak(tweak [32]byte, isXonly bool) (*KeyAggContext, error) {
+	N := schnorr.Curve.N
+	qx, qy := ctx.qx, ctx.qy
+	g := big.NewInt(1)
+	if isXonly && !hasEvenY(qy) {
+		g.Sub(N, g)
+		qy = new(big.Int).Sub(schnorr.Curve.P, qy)
+	}
+	t := new(big.Int).SetBytes(tweak[:])
+	if t.Cmp(N) >= 0 {
+		return nil, errors.New("the tweak must be less than n")
+	}
+	tGx, tGy := schnorr.Curve.ScalarBaseMult(tweak[:])
+	qx, qy = schnorr.Curve.Add(qx, qy, tGx, tGy)
+	if isInfinity(qx, qy) {
+		return nil, errors.New("the result of tweaking cannot be infinity")
+	}
+
+	gacc := new(big.Int).Mul(g, ctx.gacc)
+	gacc.Mod(gacc, N)
+	tacc := new(big.Int).Mul(g, ctx.tacc)
+	tacc.Add(tacc, t)
+	tacc.Mod(tacc, N)
+	return &KeyAggContext{
+		pubkeys:  ctx.pubkeys,
+		keysHash: ctx.keysHash,
+		pk2:      ctx.pk2,
+		qx:       qx,
+		qy:       qy,
+		gacc:     gacc,
+		tacc:     tacc,
+	}, nil
+}
+
+// PubKeys 参与聚合的公钥
+func (ctx *KeyAggContext) PubKeys() [][33]byte {
+	return append([][33]byte{}, ctx.pubkeys...)
+}
+
+// XonlyPubKey 聚合公钥的 x 坐标，即最终 BIP-340 签名对应的公钥
+func (ctx *KeyAggContext) XonlyPubKey() [32]byte {
+	var ret [32]byte
+	copy(ret[:], schnorr.IntToByte(ctx.qx))
+	return ret
+}
+
+// PlainPubKey 聚合公钥的压缩格式
+func (ctx *KeyAggContext) PlainPubKey() [33]byte {
+	var ret [33]byte
+	copy(ret[:], schnorr.Marshal(schnorr.Curve, ctx.qx, ctx.qy))
+	return ret
+}
+
+// KeyAggCoeff 公钥 pk 的聚合系数，pk 必须在公钥集合中
+func (ctx *KeyAggContext) KeyAggCoeff(pk [33]byte) (*big.Int, error) {
+	for _, key := range ctx.pubkeys {
+		if key == pk {
+			return ctx.coefficient(pk), nil
+		}
+	}
+	return nil, errors.New("the signer's pubkey must be included in the list of pubkeys")
+}
+
+func (ctx *KeyAggContext) coefficient(pk [33]byte) *big.Int {
+	if ctx.pk2 != nil && *ctx.pk2 == pk {
+		return big.NewInt(1)
+	}
+	h := schnorr.TaggedHash(keyAggCoeffTag, ctx.keysHash[:], pk[:])
+	a := new(big.Int).SetBytes(h[:])
+	return a.Mod(a, schnorr.Curve.N)
+}
+
+func hashKeys(pubkeys [][33]byte) [32]byte {
+	var buf []byte
+	for _, pk := range pubkeys {
+		buf = append(buf, pk[:]...)
+	}
+	return schnorr.TaggedHash(keyAggListTag, buf)
+}
+
+// secondKey 第一个和 pubkeys[0] 不同的公钥，没有则返回 nil
+func secondKey(pubkeys [][33]byte) *[33]byte {
+	for j := 1; j < len(pubkeys); j++ {
+		if pubkeys[j] != pubkeys[0] {
+			pk2 := pubkeys[j]
+			return &pk2
+		}
+	}
+	return nil
+}
+
+// cpoint 解析33字节压缩公钥
+func cpoint(data []byte) (x, y *big.Int, err error) {
+	if len(data) != 33 {
+		return nil, nil, errors.New("invalid point")
+	}
+	if new(big.Int).SetBytes(data[1:]).Cmp(schnorr.Curve.P) >= 0 {
+		return nil, nil, errors.New("invalid point")
+	}
+	x, y = schnorr.Unmarshal(schnorr.Curve, data)
+	if x == nil {
+		return nil, nil, errors.New("invalid point")
+	}
+	return x, y, nil
+}
+
+// cpointExt 同 cpoint，33字节0表示无穷远点
+func cpointExt(data []byte) (x, y *big.Int, err error) {
+	if bytes.Equal(data, make([]byte, 33)) {
+		return schnorr.Zero, schnorr.Zero, nil
+	}
+	return cpoint(data)
+}
+
+// cbytesExt 压缩公钥，无穷远点为33字节0
+func cbytesExt(x, y *big.Int) []byte {
+	if isInfinity(x, y) {
+		return make([]byte, 33)
+	}
+	return schnorr.Marshal(schnorr.Curve, x, y)
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+func hasEvenY(y *big.Int) bool {
+	return y.Bit(0) == 0
+}
+
+// negate 计算 -P
+func negate(x, y *big.Int) (*big.Int, *big.Int) {
+	if isInfinity(x, y) {
+		return x, y
+	}
+	return x, new(big.Int).Sub(schnorr.Curve.P, y)
+}
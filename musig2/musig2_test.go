@@ -0,0 +1,612 @@
+package musig2
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"schnorr/schnorr-go/schnorr"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func loadVectors(t *testing.T, name string, v interface{}) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// decodeArrays 把十六进制字符串解析成定长数组，长度不对的返回 nil，由用例检查错误
+func decodeArrays(t *testing.T, list []string, size int) [][]byte {
+	var ret [][]byte
+	for _, s := range list {
+		b := decodeHex(t, s)
+		if len(b) != size {
+			b = nil
+		}
+		ret = append(ret, b)
+	}
+	return ret
+}
+
+func to33(b []byte) (r [33]byte) {
+	copy(r[:], b)
+	return
+}
+
+func to32(b []byte) (r [32]byte) {
+	copy(r[:], b)
+	return
+}
+
+func to66(b []byte) (r [66]byte) {
+	copy(r[:], b)
+	return
+}
+
+func pick33(all [][]byte, indices []int) [][33]byte {
+	var ret [][33]byte
+	for _, i := range indices {
+		ret = append(ret, to33(all[i]))
+	}
+	return ret
+}
+
+type vectorError struct {
+	Type    string `json:"type"`
+	Signer  *int   `json:"signer"`
+	Contrib string `json:"contrib"`
+	Message string `json:"message"`
+}
+
+// checkError 检查错误类型和向量一致
+func checkError(t *testing.T, err error, expected vectorError, comment string) {
+	if err == nil {
+		t.Fatalf("%s: expected error", comment)
+	}
+	switch expected.Type {
+	case "invalid_contribution":
+		var contribErr *InvalidContributionError
+		if !errors.As(err, &contribErr) {
+			t.Fatalf("%s: unexpected error %v", comment, err)
+		}
+		signer := -1
+		if expected.Signer != nil {
+			signer = *expected.Signer
+		}
+		if contribErr.Signer != signer || (expected.Contrib != "" && contribErr.Contrib != expected.Contrib) {
+			t.Fatalf("%s: unexpected error %v", comment, err)
+		}
+	case "value":
+		if !strings.EqualFold(strings.TrimSuffix(expected.Message, "."), err.Error()) {
+			t.Fatalf("%s: unexpected error %v", comment, err)
+		}
+	default:
+		t.Fatalf("unknown error type %s", expected.Type)
+	}
+}
+
+func keyAggWithTweaks(pubkeys [][33]byte, tweaks [][32]byte, isXonly []bool) (*KeyAggContext, error) {
+	ctx, err := KeyAgg(pubkeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, tweak := range tweaks {
+		ctx, err = ctx.ApplyTweak(tweak, isXonly[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+func TestKeySortVectors(t *testing.T) {
+	var v struct {
+		Pubkeys       []string `json:"pubkeys"`
+		SortedPubkeys []string `json:"sorted_pubkeys"`
+	}
+	loadVectors(t, "key_sort_vectors.json", &v)
+	pubkeys := decodeArrays(t, v.Pubkeys, 33)
+	sorted := decodeArrays(t, v.SortedPubkeys, 33)
+	result := KeySort(pick33(pubkeys, []int{0, 1, 2, 3, 4}))
+	for i := range result {
+		if result[i] != to33(sorted[i]) {
+			t.Fatalf("key %d: got %x", i, result[i])
+		}
+	}
+}
+
+func TestKeyAggVectors(t *testing.T) {
+	var v struct {
+		Pubkeys        []string `json:"pubkeys"`
+		Tweaks         []string `json:"tweaks"`
+		ValidTestCases []struct {
+			KeyIndices []int  `json:"key_indices"`
+			Expected   string `json:"expected"`
+		} `json:"valid_test_cases"`
+		ErrorTestCases []struct {
+			KeyIndices   []int       `json:"key_indices"`
+			TweakIndices []int       `json:"tweak_indices"`
+			IsXonly      []bool      `json:"is_xonly"`
+			Error        vectorError `json:"error"`
+			Comment      string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadVectors(t, "key_agg_vectors.json", &v)
+	pubkeys := decodeArrays(t, v.Pubkeys, 33)
+	tweaks := decodeArrays(t, v.Tweaks, 32)
+
+	for i, tc := range v.ValidTestCases {
+		ctx, err := KeyAgg(pick33(pubkeys, tc.KeyIndices))
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if xonly := ctx.XonlyPubKey(); !strings.EqualFold(hex.EncodeToString(xonly[:]), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, xonly)
+		}
+	}
+	for _, tc := range v.ErrorTestCases {
+		var tweakList [][32]byte
+		for _, j := range tc.TweakIndices {
+			tweakList = append(tweakList, to32(tweaks[j]))
+		}
+		_, err := keyAggWithTweaks(pick33(pubkeys, tc.KeyIndices), tweakList, tc.IsXonly)
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+}
+
+func TestNonceGenVectors(t *testing.T) {
+	var v struct {
+		TestCases []struct {
+			Rand     string  `json:"rand_"`
+			Sk       *string `json:"sk"`
+			Pk       string  `json:"pk"`
+			AggPk    *string `json:"aggpk"`
+			Msg      *string `json:"msg"`
+			ExtraIn  *string `json:"extra_in"`
+			Expected string  `json:"expected"`
+		} `json:"test_cases"`
+	}
+	loadVectors(t, "nonce_gen_vectors.json", &v)
+	optional := func(s *string) []byte {
+		if s == nil {
+			return nil
+		}
+		return decodeHex(t, *s)
+	}
+	for i, tc := range v.TestCases {
+		opts := &NonceGenOptions{
+			SecretKey: optional(tc.Sk),
+			AggPubKey: optional(tc.AggPk),
+			Msg:       optional(tc.Msg),
+			ExtraIn:   optional(tc.ExtraIn),
+		}
+		secnonce, pubnonce, err := nonceGen(to32(decodeHex(t, tc.Rand)), to33(decodeHex(t, tc.Pk)), opts)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		got := append(append(secnonce.k1[:], secnonce.k2[:]...), secnonce.pk[:]...)
+		if !strings.EqualFold(hex.EncodeToString(got), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, got)
+		}
+		for j, k := range [][32]byte{secnonce.k1, secnonce.k2} {
+			Rx, Ry := schnorr.Curve.ScalarBaseMult(k[:])
+			if to33(schnorr.Marshal(schnorr.Curve, Rx, Ry)) != to33(pubnonce[j*33:]) {
+				t.Fatalf("case %d: pubnonce mismatch", i)
+			}
+		}
+	}
+}
+
+func TestNonceAggVectors(t *testing.T) {
+	var v struct {
+		Pnonces        []string `json:"pnonces"`
+		ValidTestCases []struct {
+			PnonceIndices []int  `json:"pnonce_indices"`
+			Expected      string `json:"expected"`
+		} `json:"valid_test_cases"`
+		ErrorTestCases []struct {
+			PnonceIndices []int       `json:"pnonce_indices"`
+			Error         vectorError `json:"error"`
+			Comment       string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadVectors(t, "nonce_agg_vectors.json", &v)
+	pnonces := decodeArrays(t, v.Pnonces, 66)
+	pick := func(indices []int) [][66]byte {
+		var ret [][66]byte
+		for _, i := range indices {
+			ret = append(ret, to66(pnonces[i]))
+		}
+		return ret
+	}
+	for i, tc := range v.ValidTestCases {
+		aggnonce, err := NonceAgg(pick(tc.PnonceIndices))
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(aggnonce[:]), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, aggnonce)
+		}
+	}
+	for _, tc := range v.ErrorTestCases {
+		_, err := NonceAgg(pick(tc.PnonceIndices))
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+}
+
+type signVectors struct {
+	Sk             string   `json:"sk"`
+	Pubkeys        []string `json:"pubkeys"`
+	Secnonces      []string `json:"secnonces"`
+	Secnonce       string   `json:"secnonce"`
+	Pnonces        []string `json:"pnonces"`
+	Aggnonces      []string `json:"aggnonces"`
+	Aggnonce       string   `json:"aggnonce"`
+	Tweaks         []string `json:"tweaks"`
+	Msgs           []string `json:"msgs"`
+	Msg            string   `json:"msg"`
+	ValidTestCases []struct {
+		KeyIndices    []int  `json:"key_indices"`
+		NonceIndices  []int  `json:"nonce_indices"`
+		AggnonceIndex int    `json:"aggnonce_index"`
+		MsgIndex      int    `json:"msg_index"`
+		SignerIndex   int    `json:"signer_index"`
+		TweakIndices  []int  `json:"tweak_indices"`
+		IsXonly       []bool `json:"is_xonly"`
+		Expected      string `json:"expected"`
+		Comment       string `json:"comment"`
+	} `json:"valid_test_cases"`
+	SignErrorTestCases []struct {
+		KeyIndices    []int       `json:"key_indices"`
+		AggnonceIndex int         `json:"aggnonce_index"`
+		MsgIndex      int         `json:"msg_index"`
+		SecnonceIndex int         `json:"secnonce_index"`
+		Error         vectorError `json:"error"`
+		Comment       string      `json:"comment"`
+	} `json:"sign_error_test_cases"`
+	VerifyFailTestCases []struct {
+		Sig          string `json:"sig"`
+		KeyIndices   []int  `json:"key_indices"`
+		NonceIndices []int  `json:"nonce_indices"`
+		MsgIndex     int    `json:"msg_index"`
+		SignerIndex  int    `json:"signer_index"`
+		Comment      string `json:"comment"`
+	} `json:"verify_fail_test_cases"`
+	VerifyErrorTestCases []struct {
+		Sig          string      `json:"sig"`
+		KeyIndices   []int       `json:"key_indices"`
+		NonceIndices []int       `json:"nonce_indices"`
+		MsgIndex     int         `json:"msg_index"`
+		SignerIndex  int         `json:"signer_index"`
+		Error        vectorError `json:"error"`
+		Comment      string      `json:"comment"`
+	} `json:"verify_error_test_cases"`
+	ErrorTestCases []struct {
+		KeyIndices   []int       `json:"key_indices"`
+		NonceIndices []int       `json:"nonce_indices"`
+		TweakIndices []int       `json:"tweak_indices"`
+		IsXonly      []bool      `json:"is_xonly"`
+		SignerIndex  int         `json:"signer_index"`
+		Error        vectorError `json:"error"`
+		Comment      string      `json:"comment"`
+	} `json:"error_test_cases"`
+}
+
+func newSecNonce(b []byte) *SecNonce {
+	s := &SecNonce{}
+	copy(s.k1[:], b[:32])
+	copy(s.k2[:], b[32:64])
+	copy(s.pk[:], b[64:97])
+	return s
+}
+
+// pickNonces 取出 pubnonce，长度不对的返回出错的序号
+func pickNonces(all [][]byte, indices []int) ([][66]byte, int) {
+	var ret [][66]byte
+	for i, j := range indices {
+		if all[j] == nil {
+			return nil, i
+		}
+		ret = append(ret, to66(all[j]))
+	}
+	return ret, -1
+}
+
+func TestSignVerifyVectors(t *testing.T) {
+	var v signVectors
+	loadVectors(t, "sign_verify_vectors.json", &v)
+	sk := to32(decodeHex(t, v.Sk))
+	pubkeys := decodeArrays(t, v.Pubkeys, 33)
+	pnonces := decodeArrays(t, v.Pnonces, 66)
+	aggnonces := decodeArrays(t, v.Aggnonces, 66)
+	var msgs [][]byte
+	for _, m := range v.Msgs {
+		msgs = append(msgs, decodeHex(t, m))
+	}
+
+	for i, tc := range v.ValidTestCases {
+		keys := pick33(pubkeys, tc.KeyIndices)
+		keyAgg, err := KeyAgg(keys)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		nonces, _ := pickNonces(pnonces, tc.NonceIndices)
+		aggnonce, err := NonceAgg(nonces)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if aggnonce != to66(aggnonces[tc.AggnonceIndex]) {
+			t.Fatalf("case %d: aggnonce mismatch", i)
+		}
+		session, err := NewSessionContext(aggnonce, keyAgg, msgs[tc.MsgIndex])
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		psig, err := Sign(newSecNonce(decodeHex(t, v.Secnonces[0])), sk, session)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(psig[:]), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, psig)
+		}
+		ret, err := PartialSigVerify(psig, nonces, keys, nil, nil, msgs[tc.MsgIndex], tc.SignerIndex)
+		if err != nil || !ret {
+			t.Fatalf("case %d: partial signature verification failed %v", i, err)
+		}
+	}
+
+	for _, tc := range v.SignErrorTestCases {
+		err := func() error {
+			keyAgg, err := KeyAgg(pick33(pubkeys, tc.KeyIndices))
+			if err != nil {
+				return err
+			}
+			session, err := NewSessionContext(to66(aggnonces[tc.AggnonceIndex]), keyAgg, msgs[tc.MsgIndex])
+			if err != nil {
+				return err
+			}
+			_, err = Sign(newSecNonce(decodeHex(t, v.Secnonces[tc.SecnonceIndex])), sk, session)
+			return err
+		}()
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+
+	for _, tc := range v.VerifyFailTestCases {
+		nonces, _ := pickNonces(pnonces, tc.NonceIndices)
+		ret, err := PartialSigVerify(to32(decodeHex(t, tc.Sig)), nonces, pick33(pubkeys, tc.KeyIndices), nil, nil, msgs[tc.MsgIndex], tc.SignerIndex)
+		if err != nil || ret {
+			t.Fatalf("%s: verification should fail, err %v", tc.Comment, err)
+		}
+	}
+
+	for _, tc := range v.VerifyErrorTestCases {
+		nonces, bad := pickNonces(pnonces, tc.NonceIndices)
+		if bad >= 0 {
+			// pubnonce 长度不对，解析时就会失败
+			_, err := ParsePubNonce(decodeHex(t, v.Pnonces[tc.NonceIndices[bad]]))
+			if err == nil || tc.Error.Contrib != "pubnonce" || *tc.Error.Signer != bad {
+				t.Fatalf("%s: unexpected result %v", tc.Comment, err)
+			}
+			continue
+		}
+		_, err := PartialSigVerify(to32(decodeHex(t, tc.Sig)), nonces, pick33(pubkeys, tc.KeyIndices), nil, nil, msgs[tc.MsgIndex], tc.SignerIndex)
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+}
+
+func TestTweakVectors(t *testing.T) {
+	var v signVectors
+	loadVectors(t, "tweak_vectors.json", &v)
+	sk := to32(decodeHex(t, v.Sk))
+	pubkeys := decodeArrays(t, v.Pubkeys, 33)
+	pnonces := decodeArrays(t, v.Pnonces, 66)
+	tweaks := decodeArrays(t, v.Tweaks, 32)
+	aggnonce := to66(decodeHex(t, v.Aggnonce))
+	msg := decodeHex(t, v.Msg)
+	pickTweaks := func(indices []int) [][32]byte {
+		var ret [][32]byte
+		for _, i := range indices {
+			ret = append(ret, to32(tweaks[i]))
+		}
+		return ret
+	}
+
+	for i, tc := range v.ValidTestCases {
+		keys := pick33(pubkeys, tc.KeyIndices)
+		tweakList := pickTweaks(tc.TweakIndices)
+		keyAgg, err := keyAggWithTweaks(keys, tweakList, tc.IsXonly)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		session, err := NewSessionContext(aggnonce, keyAgg, msg)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		psig, err := Sign(newSecNonce(decodeHex(t, v.Secnonce)), sk, session)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(psig[:]), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, psig)
+		}
+		nonces, _ := pickNonces(pnonces, tc.NonceIndices)
+		ret, err := PartialSigVerify(psig, nonces, keys, tweakList, tc.IsXonly, msg, tc.SignerIndex)
+		if err != nil || !ret {
+			t.Fatalf("case %d: partial signature verification failed %v", i, err)
+		}
+	}
+	for _, tc := range v.ErrorTestCases {
+		_, err := keyAggWithTweaks(pick33(pubkeys, tc.KeyIndices), pickTweaks(tc.TweakIndices), tc.IsXonly)
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+}
+
+func TestSigAggVectors(t *testing.T) {
+	var v struct {
+		Pubkeys        []string `json:"pubkeys"`
+		Pnonces        []string `json:"pnonces"`
+		Tweaks         []string `json:"tweaks"`
+		Psigs          []string `json:"psigs"`
+		Msg            string   `json:"msg"`
+		ValidTestCases []struct {
+			Aggnonce     string `json:"aggnonce"`
+			NonceIndices []int  `json:"nonce_indices"`
+			KeyIndices   []int  `json:"key_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			IsXonly      []bool `json:"is_xonly"`
+			PsigIndices  []int  `json:"psig_indices"`
+			Expected     string `json:"expected"`
+		} `json:"valid_test_cases"`
+		ErrorTestCases []struct {
+			Aggnonce     string      `json:"aggnonce"`
+			NonceIndices []int       `json:"nonce_indices"`
+			KeyIndices   []int       `json:"key_indices"`
+			TweakIndices []int       `json:"tweak_indices"`
+			IsXonly      []bool      `json:"is_xonly"`
+			PsigIndices  []int       `json:"psig_indices"`
+			Error        vectorError `json:"error"`
+			Comment      string      `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadVectors(t, "sig_agg_vectors.json", &v)
+	pubkeys := decodeArrays(t, v.Pubkeys, 33)
+	pnonces := decodeArrays(t, v.Pnonces, 66)
+	tweaks := decodeArrays(t, v.Tweaks, 32)
+	psigs := decodeArrays(t, v.Psigs, 32)
+	msg := decodeHex(t, v.Msg)
+
+	aggregate := func(aggnonceHex string, nonceIndices, keyIndices, tweakIndices, psigIndices []int, isXonly []bool) ([64]byte, error) {
+		nonces, _ := pickNonces(pnonces, nonceIndices)
+		aggnonce, err := NonceAgg(nonces)
+		if err != nil {
+			return [64]byte{}, err
+		}
+		if aggnonce != to66(decodeHex(t, aggnonceHex)) {
+			t.Fatal("aggnonce mismatch")
+		}
+		var tweakList [][32]byte
+		for _, i := range tweakIndices {
+			tweakList = append(tweakList, to32(tweaks[i]))
+		}
+		keyAgg, err := keyAggWithTweaks(pick33(pubkeys, keyIndices), tweakList, isXonly)
+		if err != nil {
+			return [64]byte{}, err
+		}
+		session, err := NewSessionContext(aggnonce, keyAgg, msg)
+		if err != nil {
+			return [64]byte{}, err
+		}
+		var psigList [][32]byte
+		for _, i := range psigIndices {
+			psigList = append(psigList, to32(psigs[i]))
+		}
+		sig, err := PartialSigAgg(psigList, session)
+		if err != nil {
+			return sig, err
+		}
+		if ret, _ := schnorr.VerifyBIP340(keyAgg.XonlyPubKey(), msg, sig); !ret {
+			t.Fatal("aggregated signature verification failed")
+		}
+		return sig, nil
+	}
+
+	for i, tc := range v.ValidTestCases {
+		sig, err := aggregate(tc.Aggnonce, tc.NonceIndices, tc.KeyIndices, tc.TweakIndices, tc.PsigIndices, tc.IsXonly)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(sig[:]), tc.Expected) {
+			t.Fatalf("case %d: got %x", i, sig)
+		}
+	}
+	for _, tc := range v.ErrorTestCases {
+		_, err := aggregate(tc.Aggnonce, tc.NonceIndices, tc.KeyIndices, tc.TweakIndices, tc.PsigIndices, tc.IsXonly)
+		checkError(t, err, tc.Error, tc.Comment)
+	}
+}
+
+func TestSignConcurrently(t *testing.T) {
+	msg := []byte("test msg")
+	var sks [][32]byte
+	var pubkeys [][33]byte
+	for i := 0; i < 5; i++ {
+		sk, pk := schnorr.GenKey()
+		sks = append(sks, sk)
+		pubkeys = append(pubkeys, pk)
+	}
+	keyAgg, err := KeyAgg(KeySort(pubkeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyAgg, err = keyAgg.ApplyTweak(schnorr.TaggedHash("TapTweak", []byte("data")), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggpk := keyAgg.XonlyPubKey()
+
+	var secnonces []*SecNonce
+	var pubnonces [][66]byte
+	for i, sk := range sks {
+		secnonce, pubnonce, err := NonceGen(pubkeys[i], &NonceGenOptions{SecretKey: sk[:], AggPubKey: aggpk[:], Msg: msg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		secnonces = append(secnonces, secnonce)
+		pubnonces = append(pubnonces, pubnonce)
+	}
+	aggnonce, err := NonceAgg(pubnonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := NewSessionContext(aggnonce, keyAgg, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	psigs := make([][32]byte, len(sks))
+	errs := make([]error, len(sks))
+	var wg sync.WaitGroup
+	for i := range sks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			psigs[i], errs[i] = Sign(secnonces[i], sks[i], session)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret, err := PartialSigVerifyInternal(psigs[i], pubnonces[i], pubkeys[i], session); err != nil || !ret {
+			t.Fatal("partial signature verification failed", err)
+		}
+	}
+	sig, err := PartialSigAgg(psigs, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret, _ := schnorr.VerifyBIP340(aggpk, msg, sig); !ret {
+		t.Fatal("signature verification failed")
+	}
+
+	// secnonce 已经用过，不能再签名
+	if _, err := Sign(secnonces[0], sks[0], session); err == nil {
+		t.Fatal("secnonce reused")
+	}
+}
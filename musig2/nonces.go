@@ -0,0 +1,139 @@
+package musig2
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+	"sync"
+)
+
+const (
+	auxTag   = "MuSig/aux"
+	nonceTag = "MuSig/nonce"
+)
+
+// SecNonce 签名者的秘密随机数 k1, k2
+// 只能被 Sign 使用一次，用过之后清零，多个协程同时使用时只有一个能成功
+type SecNonce struct {
+	mu     sync.Mutex
+	k1, k2 [32]byte
+	pk     [33]byte
+}
+
+// PublicKey 生成随机数时使用的公钥
+func (s *SecNonce) PublicKey() [33]byte {
+	return s.pk
+}
+
+// take 取出 k1, k2 并清零
+func (s *SecNonce) take() (k1, k2 [32]byte, pk [33]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k1, k2 = s.k1, s.k2
+	s.k1, s.k2 = [32]byte{}, [32]byte{}
+	return k1, k2, s.pk
+}
+
+// NonceGenOptions NonceGen 的可选参数，提供的越多，随机数生成器有问题时越安全
+type NonceGenOptions struct {
+	// SecretKey 签名私钥，32字节
+	SecretKey []byte
+	// AggPubKey 聚合公钥的 x 坐标，32字节
+	AggPubKey []byte
+	// Msg 待签名消息，nil 表示不提供，空切片表示空消息
+	Msg []byte
+	// ExtraIn 其他附加数据，例如会话id
+	ExtraIn []byte
+}
+
+// NonceGen 生成一对随机数，secnonce 自己保存，pubnonce 发给其他签名者
+func NonceGen(pk [33]byte, opts *NonceGenOptions) (*SecNonce, [66]byte, error) {
+	var randBytes [32]byte
+	if _, err := rand.Read(randBytes[:]); err != nil {
+		return nil, [66]byte{}, err
+	}
+	return nonceGen(randBytes, pk, opts)
+}
+
+func nonceGen(randBytes [32]byte, pk [33]byte, opts *NonceGenOptions) (*SecNonce, [66]byte, error) {
+	var pubnonce [66]byte
+	if opts == nil {
+		opts = &NonceGenOptions{}
+	}
+	if len(opts.SecretKey) != 0 && len(opts.SecretKey) != 32 {
+		return nil, pubnonce, errors.New("invalid secret key")
+	}
+	if len(opts.AggPubKey) != 0 && len(opts.AggPubKey) != 32 {
+		return nil, pubnonce, errors.New("invalid aggregate public key")
+	}
+
+	if len(opts.SecretKey) != 0 {
+		h := schnorr.TaggedHash(auxTag, randBytes[:])
+		for i := range randBytes {
+			randBytes[i] = opts.SecretKey[i] ^ h[i]
+		}
+	}
+	msgPrefixed := []byte{0}
+	if opts.Msg != nil {
+		msgPrefixed = make([]byte, 9, 9+len(opts.Msg))
+		msgPrefixed[0] = 1
+		binary.BigEndian.PutUint64(msgPrefixed[1:], uint64(len(opts.Msg)))
+		msgPrefixed = append(msgPrefixed, opts.Msg...)
+	}
+	var extraLen [4]byte
+	binary.BigEndian.PutUint32(extraLen[:], uint32(len(opts.ExtraIn)))
+
+	secnonce := &SecNonce{pk: pk}
+	for i, k := range []*[32]byte{&secnonce.k1, &secnonce.k2} {
+		h := schnorr.TaggedHash(nonceTag, randBytes[:], []byte{byte(len(pk))}, pk[:],
+			[]byte{byte(len(opts.AggPubKey))}, opts.AggPubKey, msgPrefixed, extraLen[:], opts.ExtraIn, []byte{byte(i)})
+		kScalar, err := schnorr.NewScalar(h)
+		if err != nil {
+			// h >= N 的概率可以忽略，这时按 BIP-327 规约到 [0, N)
+			kScalar = new(schnorr.Scalar).SetBig(new(big.Int).SetBytes(h[:]))
+		}
+		if kScalar.IsZero() {
+			return nil, pubnonce, errors.New("invalid nonce")
+		}
+		*k = kScalar.Bytes()
+		R := new(schnorr.Point).BaseMul(kScalar).Bytes()
+		copy(pubnonce[i*33:], R[:])
+	}
+	return secnonce, pubnonce, nil
+}
+
+// ParsePubNonce 解析其他签名者发来的 pubnonce
+func ParsePubNonce(data []byte) (pubnonce [66]byte, err error) {
+	if len(data) != 66 {
+		return pubnonce, errors.New("invalid pubnonce")
+	}
+	for j := 0; j < 2; j++ {
+		if _, _, err := cpoint(data[j*33 : j*33+33]); err != nil {
+			return pubnonce, errors.New("invalid pubnonce")
+		}
+	}
+	copy(pubnonce[:], data)
+	return pubnonce, nil
+}
+
+// NonceAgg 聚合所有签名者的 pubnonce
+// R'j = R1j + R2j + ... + Ruj, j = 1, 2
+func NonceAgg(pubnonces [][66]byte) (aggnonce [66]byte, err error) {
+	if len(pubnonces) == 0 {
+		return aggnonce, errors.New("invalid pubnonces")
+	}
+	for j := 0; j < 2; j++ {
+		Rx, Ry := schnorr.Zero, schnorr.Zero
+		for i, pubnonce := range pubnonces {
+			RIx, RIy, err := cpoint(pubnonce[j*33 : j*33+33])
+			if err != nil {
+				return aggnonce, &InvalidContributionError{Signer: i, Contrib: "pubnonce"}
+			}
+			Rx, Ry = schnorr.Curve.Add(Rx, Ry, RIx, RIy)
+		}
+		copy(aggnonce[j*33:], cbytesExt(Rx, Ry))
+	}
+	return aggnonce, nil
+}
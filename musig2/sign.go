@@ -0,0 +1,219 @@
+package musig2
+
+import (
+	"errors"
+	"math/big"
+	"schnorr/schnorr-go/schnorr"
+)
+
+const (
+	nonceCoefTag = "MuSig/noncecoef"
+	challengeTag = "BIP0340/challenge"
+)
+
+// SessionContext 一次签名所有签名者共同的参数
+// 创建时计算 b, R, e，之后不再修改，可以在多个协程中使用
+type SessionContext struct {
+	keyAgg *KeyAggContext
+	msg    []byte
+	b      *big.Int
+	rx, ry *big.Int
+	e      *big.Int
+}
+
+// NewSessionContext 由聚合随机数、聚合公钥(已经加上所有 tweak)和消息创建签名会话
+// b = TaggedHash("MuSig/noncecoef", aggnonce||Qx||msg)
+// R = R'1 + b*R'2
+// e = TaggedHash("BIP0340/challenge", Rx||Qx||msg)
+func NewSessionContext(aggnonce [66]byte, keyAgg *KeyAggContext, msg []byte) (*SessionContext, error) {
+	N := schnorr.Curve.N
+	qx := keyAgg.XonlyPubKey()
+	h := schnorr.TaggedHash(nonceCoefTag, aggnonce[:], qx[:], msg)
+	b := new(big.Int).SetBytes(h[:])
+	b.Mod(b, N)
+
+	R1x, R1y, err := cpointExt(aggnonce[:33])
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	R2x, R2y, err := cpointExt(aggnonce[33:])
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	bR2x, bR2y := schnorr.Curve.ScalarMult(R2x, R2y, schnorr.IntToByte(b))
+	Rx, Ry := schnorr.Curve.Add(R1x, R1y, bR2x, bR2y)
+	if isInfinity(Rx, Ry) {
+		Rx, Ry = schnorr.Curve.Gx, schnorr.Curve.Gy
+	}
+
+	h = schnorr.TaggedHash(challengeTag, schnorr.IntToByte(Rx), qx[:], msg)
+	e := new(big.Int).SetBytes(h[:])
+	e.Mod(e, N)
+	return &SessionContext{
+		keyAgg: keyAgg,
+		msg:    append([]byte{}, msg...),
+		b:      b,
+		rx:     Rx,
+		ry:     Ry,
+		e:      e,
+	}, nil
+}
+
+// Sign 生成部分签名 s = k1 + b*k2 + e*a*d mod N
+// secnonce 使用后清零，同一个 secnonce 不能签第二次
+func Sign(secnonce *SecNonce, sk [32]byte, session *SessionContext) (psig [32]byte, err error) {
+	// 随机数和私钥是秘密的，只用固定时间的 Scalar 和 Point.BaseMul 计算
+	k1b, k2b, noncePk := secnonce.take()
+	k1, err := schnorr.NewScalar(k1b)
+	if err != nil || k1.IsZero() {
+		return psig, errors.New("first secnonce value is out of range")
+	}
+	k2, err := schnorr.NewScalar(k2b)
+	if err != nil || k2.IsZero() {
+		return psig, errors.New("second secnonce value is out of range")
+	}
+	var pubnonce [66]byte
+	R1 := new(schnorr.Point).BaseMul(k1).Bytes()
+	R2 := new(schnorr.Point).BaseMul(k2).Bytes()
+	copy(pubnonce[:33], R1[:])
+	copy(pubnonce[33:], R2[:])
+	if !hasEvenY(session.ry) {
+		k1.Neg(k1)
+		k2.Neg(k2)
+	}
+
+	d, err := schnorr.NewScalar(sk)
+	if err != nil || d.IsZero() {
+		return psig, errors.New("secret key value is out of range")
+	}
+	pk := new(schnorr.Point).BaseMul(d).Bytes()
+	if pk != noncePk {
+		return psig, errors.New("public key does not match nonce_gen argument")
+	}
+	a, err := session.keyAgg.KeyAggCoeff(pk)
+	if err != nil {
+		return psig, err
+	}
+
+	// d = g*gacc*d'
+	keyAgg := session.keyAgg
+	if !hasEvenY(keyAgg.qy) {
+		d.Neg(d)
+	}
+	d.Mul(d, new(schnorr.Scalar).SetBig(keyAgg.gacc))
+	// s = k1 + b*k2 + e*a*d
+	s := new(schnorr.Scalar).SetBig(session.e)
+	s.Mul(s, new(schnorr.Scalar).SetBig(a))
+	s.Mul(s, d)
+	s.Add(s, k1)
+	s.Add(s, k2.Mul(k2, new(schnorr.Scalar).SetBig(session.b)))
+	psig = s.Bytes()
+
+	ret, err := PartialSigVerifyInternal(psig, pubnonce, pk, session)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if !ret {
+		return [32]byte{}, errors.New("partial signature verification failed")
+	}
+	return psig, nil
+}
+
+// PartialSigVerify 验证第 i 个签名者的部分签名
+func PartialSigVerify(psig [32]byte, pubnonces [][66]byte, pubkeys [][33]byte, tweaks [][32]byte, isXonly []bool, msg []byte, i int) (bool, error) {
+	if i < 0 || i >= len(pubnonces) || len(pubnonces) != len(pubkeys) {
+		return false, errors.New("invalid index")
+	}
+	if len(tweaks) != len(isXonly) {
+		return false, errors.New("tweaks size is not equal to isXonly")
+	}
+	aggnonce, err := NonceAgg(pubnonces)
+	if err != nil {
+		return false, err
+	}
+	keyAgg, err := KeyAgg(pubkeys)
+	if err != nil {
+		return false, err
+	}
+	for j, tweak := range tweaks {
+		keyAgg, err = keyAgg.ApplyTweak(tweak, isXonly[j])
+		if err != nil {
+			return false, err
+		}
+	}
+	session, err := NewSessionContext(aggnonce, keyAgg, msg)
+	if err != nil {
+		return false, err
+	}
+	return PartialSigVerifyInternal(psig, pubnonces[i], pubkeys[i], session)
+}
+
+// PartialSigVerifyInternal 在已有的签名会话中验证一个部分签名
+// s*G = R* + e*a*g'*P, R* = ±(R1 + b*R2)
+func PartialSigVerifyInternal(psig [32]byte, pubnonce [66]byte, pk [33]byte, session *SessionContext) (bool, error) {
+	N := schnorr.Curve.N
+	s := new(big.Int).SetBytes(psig[:])
+	if s.Cmp(N) >= 0 {
+		return false, nil
+	}
+	R1x, R1y, err := cpoint(pubnonce[:33])
+	if err != nil {
+		return false, &InvalidContributionError{Signer: -1, Contrib: "pubnonce"}
+	}
+	R2x, R2y, err := cpoint(pubnonce[33:])
+	if err != nil {
+		return false, &InvalidContributionError{Signer: -1, Contrib: "pubnonce"}
+	}
+	Px, Py, err := cpoint(pk[:])
+	if err != nil {
+		return false, &InvalidContributionError{Signer: -1, Contrib: "pubkey"}
+	}
+	a, err := session.keyAgg.KeyAggCoeff(pk)
+	if err != nil {
+		return false, err
+	}
+
+	bR2x, bR2y := schnorr.Curve.ScalarMult(R2x, R2y, schnorr.IntToByte(session.b))
+	Rx, Ry := schnorr.Curve.Add(R1x, R1y, bR2x, bR2y)
+	if !hasEvenY(session.ry) {
+		Rx, Ry = negate(Rx, Ry)
+	}
+
+	keyAgg := session.keyAgg
+	g := new(big.Int).Set(keyAgg.gacc)
+	if !hasEvenY(keyAgg.qy) {
+		g.Sub(N, g)
+	}
+	c := new(big.Int).Mul(session.e, a)
+	c.Mul(c, g)
+	c.Mod(c, N)
+	cPx, cPy := schnorr.Curve.ScalarMult(Px, Py, schnorr.IntToByte(c))
+	Rx, Ry = schnorr.Curve.Add(Rx, Ry, cPx, cPy)
+
+	sGx, sGy := schnorr.Curve.ScalarBaseMult(psig[:])
+	return sGx.Cmp(Rx) == 0 && sGy.Cmp(Ry) == 0, nil
+}
+
+// PartialSigAgg 聚合所有部分签名，得到 BIP-340 签名 (Rx, s)
+// s = s1 + s2 + ... + su + e*g*tacc
+func PartialSigAgg(psigs [][32]byte, session *SessionContext) (sig [64]byte, err error) {
+	N := schnorr.Curve.N
+	s := new(big.Int)
+	for i, psig := range psigs {
+		si := new(big.Int).SetBytes(psig[:])
+		if si.Cmp(N) >= 0 {
+			return sig, &InvalidContributionError{Signer: i, Contrib: "psig"}
+		}
+		s.Add(s, si)
+	}
+	keyAgg := session.keyAgg
+	t := new(big.Int).Mul(session.e, keyAgg.tacc)
+	if !hasEvenY(keyAgg.qy) {
+		t.Neg(t)
+	}
+	s.Add(s, t)
+	s.Mod(s, N)
+	copy(sig[:32], schnorr.IntToByte(session.rx))
+	copy(sig[32:], schnorr.IntToByte(s))
+	return sig, nil
+}
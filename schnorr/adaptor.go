@@ -0,0 +1,152 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+var (
+	errInvalidPreNonce   = fmt.Errorf("%w: invalid nonce", ErrInvalidPoint)
+	errSignatureMismatch = fmt.Errorf("%w: signature does not match pre-signature", ErrVerificationFailed)
+)
+
+// 适配器签名(预签名)
+// 签名者只知道 T = t*G，不知道 t，用随机数点 R* = R + T 计算 e，得到预签名 (R, s')
+// R*y 是二次剩余时 s' = k + e*d，补全 s = s' + t；否则 s' = -k + e*d，补全 s = s' - t
+// 最终签名 (R*x, s) 可以被 Verify 验证；拿到最终签名和预签名的人可以反推出 t
+
+// PreSign 生成绑定到 T 的预签名 R||s'
+// privateKey.K0 是 GenNonce 生成的随机数，只能使用一次
+func PreSign(message []byte, privateKey *PrivateKey, T [33]byte) (preSig [65]byte, err error) {
+	if err := checkPrivateKey(privateKey); err != nil {
+		return preSig, err
+	}
+	Px, Py := ScalarBaseMult(privateKey.D)
+	Rx, Ry := ScalarBaseMult(privateKey.K0)
+	publicKey := &PublicKey{}
+	copy(publicKey.P[:], Marshal(Curve, Px, Py))
+	copy(publicKey.R[:], Marshal(Curve, Rx, Ry))
+
+	_, _, s, err := Sign(message, privateKey, []*PublicKey{publicKey}, WithAdaptor(T))
+	if err != nil {
+		return preSig, err
+	}
+	copy(preSig[:33], publicKey.R[:])
+	copy(preSig[33:], IntToByte(s))
+	return preSig, nil
+}
+
+// PreVerify 验证预签名 s'*G - e*P = ±R，e 由 R + T 计算
+func PreVerify(publicKey [33]byte, message []byte, preSig [65]byte, T [33]byte) (bool, error) {
+	P, ok := decompress(publicKey[:])
+	if !ok {
+		return false, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	Rx, Ry := Unmarshal(Curve, preSig[:33])
+	if Rx == nil {
+		return false, errInvalidPreNonce
+	}
+	s := new(big.Int).SetBytes(preSig[33:])
+	if s.Cmp(Curve.N) >= 0 {
+		return false, fmt.Errorf("%w: s is larger than or equal to curve order", ErrScalarOutOfRange)
+	}
+	RAx, RAy, err := adaptorNonce(Rx, Ry, T)
+	if err != nil {
+		return false, err
+	}
+
+	e := getE(P.x.big(), P.y.big(), IntToByte(RAx), message)
+	x1, y1, ok := verifyPoint(s, e, &P)
+	if !ok {
+		return false, fmt.Errorf("%w: pre-signature", ErrVerificationFailed)
+	}
+	if big.Jacobi(RAy, Curve.P) != 1 {
+		y1.neg(&y1)
+	}
+	if x1.big().Cmp(Rx) != 0 || y1.big().Cmp(Ry) != 0 {
+		return false, fmt.Errorf("%w: pre-signature", ErrVerificationFailed)
+	}
+	return true, nil
+}
+
+// MultiPreVerify 验证多人的预签名，publicKeys 和 opts 必须和签名时一致
+// 多人预签名中的 R 为所有参与者 R 的和
+func MultiPreVerify(publicKeys [][33]byte, message []byte, preSig [65]byte, T [33]byte, opts ...Option) (bool, error) {
+	ctx, err := NewKeyAggContext(publicKeys, opts...)
+	if err != nil {
+		return false, err
+	}
+	return PreVerify(ctx.publicKey, ctx.o.message(ctx.agg, message), preSig, T)
+}
+
+// Adapt 用 t 把预签名补全成普通签名
+func Adapt(preSig [65]byte, t [32]byte) (signature [64]byte, err error) {
+	Rx, Ry := Unmarshal(Curve, preSig[:33])
+	if Rx == nil {
+		return signature, errInvalidPreNonce
+	}
+	// t 是秘密的，只参与固定时间的运算
+	var tk scalar
+	if tk.setBytes(&t)|tk.isZero() != 0 {
+		return signature, fmt.Errorf("%w: invalid adaptor secret", ErrScalarOutOfRange)
+	}
+	Tx, Ty := baseMult(&tk)
+	var T [33]byte
+	copy(T[:], Marshal(Curve, Tx, Ty))
+	RAx, RAy, err := adaptorNonce(Rx, Ry, T)
+	if err != nil {
+		return signature, err
+	}
+
+	var sb [32]byte
+	copy(sb[:], preSig[33:])
+	var s scalar
+	if s.setBytes(&sb) != 0 {
+		return signature, fmt.Errorf("%w: s is larger than or equal to curve order", ErrScalarOutOfRange)
+	}
+	var flag uint64
+	if big.Jacobi(RAy, Curve.P) != 1 {
+		flag = 1
+	}
+	s.add(&s, tk.condNeg(flag))
+	sb = s.bytes()
+	copy(signature[:32], IntToByte(RAx))
+	copy(signature[32:], sb[:])
+	return signature, nil
+}
+
+// Extract 由最终签名和预签名恢复 t
+// t = s - s' 或 s' - s，用 R + t*G 的x坐标等于 r 确定是哪一个
+func Extract(signature [64]byte, preSig [65]byte) (t [32]byte, err error) {
+	Rx, Ry := Unmarshal(Curve, preSig[:33])
+	if Rx == nil {
+		return t, errInvalidPreNonce
+	}
+	r, tInt, err := parseSignature(signature)
+	if err != nil {
+		return t, err
+	}
+	tInt.Sub(tInt, new(big.Int).SetBytes(preSig[33:]))
+	tInt.Mod(tInt, Curve.N)
+	if tInt.Sign() == 0 {
+		return t, errSignatureMismatch
+	}
+	for _, candidate := range []*big.Int{tInt, new(big.Int).Sub(Curve.N, tInt)} {
+		Tx, Ty := Curve.ScalarBaseMult(IntToByte(candidate))
+		RAx, RAy := Curve.Add(Rx, Ry, Tx, Ty)
+		if RAx.Cmp(r) != 0 {
+			continue
+		}
+		// s = s' + t 时 R*y 是二次剩余，s = s' - t 时不是
+		if (big.Jacobi(RAy, Curve.P) == 1) == (candidate == tInt) {
+			copy(t[:], IntToByte(candidate))
+			return t, nil
+		}
+	}
+	return t, errSignatureMismatch
+}
+
+// adaptorNonce 计算 R + T
+func adaptorNonce(Rx, Ry *big.Int, T [33]byte) (x, y *big.Int, err error) {
+	return newOptions([]Option{WithAdaptor(T)}).nonce(Rx, Ry)
+}
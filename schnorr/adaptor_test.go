@@ -0,0 +1,47 @@
+package schnorr
+
+import "testing"
+
+func TestAdaptor(t *testing.T) {
+	message := []byte("test msg")
+	for i := 0; i < 8; i++ {
+		d, P := GenKey()
+		secret, T := GenKey()
+		k0, _, err := GenNonce(d, message, nil)
+		if err != nil {
+			panic(err)
+		}
+		preSig, err := PreSign(message, &PrivateKey{D: d, K0: k0}, T)
+		if err != nil {
+			panic(err)
+		}
+		if ret, err := PreVerify(P, message, preSig, T); err != nil || !ret {
+			panic("pre-signature verification failed")
+		}
+		// 预签名本身不是合法签名
+		var fake [64]byte
+		copy(fake[:], preSig[1:])
+		if ret, _ := Verify(P, message, fake); ret {
+			panic("pre-signature should not be a valid signature")
+		}
+		_, other := GenKey()
+		if ret, _ := PreVerify(P, message, preSig, other); ret {
+			panic("pre-signature should be bound to T")
+		}
+
+		signature, err := Adapt(preSig, secret)
+		if err != nil {
+			panic(err)
+		}
+		if ret, err := Verify(P, message, signature); err != nil || !ret {
+			panic("adapted signature verification failed")
+		}
+		extracted, err := Extract(signature, preSig)
+		if err != nil {
+			panic(err)
+		}
+		if extracted != secret {
+			panic("extracted secret mismatch")
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package schnorr
+
+import (
+	"math/big"
+	"sync"
+)
+
+// projectivePoint 齐次射影坐标的点 (X/Z, Y/Z)，无穷远点为 (0, 1, 0)
+// 加法使用 Renes-Costello-Batina 的完备公式(a = 0)，对任意两个点(包括相同的点和无穷远点)都成立，
+// 没有任何分支，用于和私钥、随机数有关的点乘
+type projectivePoint struct {
+	x, y, z fieldElement
+}
+
+// b3 = 3*b = 21
+var fieldB3 = fieldElement{21, 0, 0, 0}
+
+// add p = a + b (RCB 2015 算法7)
+func (p *projectivePoint) add(a, b *projectivePoint) *projectivePoint {
+	var t0, t1, t2, t3, t4, x3, y3, z3 fieldElement
+	t0.mul(&a.x, &b.x)
+	t1.mul(&a.y, &b.y)
+	t2.mul(&a.z, &b.z)
+	t3.add(&a.x, &a.y)
+	t4.add(&b.x, &b.y)
+	t3.mul(&t3, &t4)
+	t4.add(&t0, &t1)
+	t3.sub(&t3, &t4)
+	t4.add(&a.y, &a.z)
+	x3.add(&b.y, &b.z)
+	t4.mul(&t4, &x3)
+	x3.add(&t1, &t2)
+	t4.sub(&t4, &x3)
+	x3.add(&a.x, &a.z)
+	y3.add(&b.x, &b.z)
+	x3.mul(&x3, &y3)
+	y3.add(&t0, &t2)
+	y3.sub(&x3, &y3)
+	x3.add(&t0, &t0)
+	t0.add(&x3, &t0)
+	t2.mul(&fieldB3, &t2)
+	z3.add(&t1, &t2)
+	t1.sub(&t1, &t2)
+	y3.mul(&fieldB3, &y3)
+	x3.mul(&t4, &y3)
+	t2.mul(&t3, &t1)
+	x3.sub(&t2, &x3)
+	y3.mul(&y3, &t0)
+	t1.mul(&t1, &z3)
+	y3.add(&t1, &y3)
+	t0.mul(&t0, &t3)
+	z3.mul(&z3, &t4)
+	z3.add(&z3, &t0)
+	p.x, p.y, p.z = x3, y3, z3
+	return p
+}
+
+// double p = 2a (RCB 2015 算法9)
+func (p *projectivePoint) double(a *projectivePoint) *projectivePoint {
+	var t0, t1, t2, x3, y3, z3 fieldElement
+	t0.square(&a.y)
+	z3.add(&t0, &t0)
+	z3.add(&z3, &z3)
+	z3.add(&z3, &z3)
+	t1.mul(&a.y, &a.z)
+	t2.square(&a.z)
+	t2.mul(&fieldB3, &t2)
+	x3.mul(&t2, &z3)
+	y3.add(&t0, &t2)
+	z3.mul(&t1, &z3)
+	t1.add(&t2, &t2)
+	t2.add(&t1, &t2)
+	t0.sub(&t0, &t2)
+	y3.mul(&t0, &y3)
+	y3.add(&x3, &y3)
+	t1.mul(&a.x, &a.y)
+	x3.mul(&t0, &t1)
+	x3.add(&x3, &x3)
+	p.x, p.y, p.z = x3, y3, z3
+	return p
+}
+
+// cmov flag 为1时 p = a，否则不变
+func (p *projectivePoint) cmov(a *projectivePoint, flag uint64) {
+	p.x.cmov(&a.x, flag)
+	p.y.cmov(&a.y, flag)
+	p.z.cmov(&a.z, flag)
+}
+
+// setJacobian 由 Jacobian 坐标转换，(X, Y, Z) -> (X*Z, Y, Z^3)
+func (p *projectivePoint) setJacobian(a *jacobianPoint) *projectivePoint {
+	if a.isInfinity() {
+		*p = projectivePoint{y: fieldOne}
+		return p
+	}
+	p.x.mul(&a.x, &a.z)
+	p.y = a.y
+	p.z.square(&a.z)
+	p.z.mul(&p.z, &a.z)
+	return p
+}
+
+// big 转换成 big.Int 表示的仿射坐标，无穷远点为 (0, 0)
+// Z 为0时 1/Z 也是0，不需要分支
+func (p *projectivePoint) big() (x, y *big.Int) {
+	var zInv, fx, fy fieldElement
+	zInv.inv(&p.z)
+	fx.mul(&p.x, &zInv)
+	fy.mul(&p.y, &zInv)
+	return fx.big(), fy.big()
+}
+
+// baseTable[i][j] = j * 16^i * G，j = 0 时为无穷远点
+var (
+	baseTable     [64][16]projectivePoint
+	baseTableOnce sync.Once
+)
+
+func initBaseTable() {
+	B := basePoint()
+	for i := range baseTable {
+		var P jacobianPoint
+		baseTable[i][0].setJacobian(&P)
+		for j := 1; j < 16; j++ {
+			P.add(&P, &B)
+			baseTable[i][j].setJacobian(&P)
+		}
+		// B = 16 * B
+		B.add(&P, &B)
+	}
+}
+
+// baseMult 计算 k*G，运行时间和访存模式都和 k 无关
+// 把 k 分成64个4位的窗口 k = Σ ki*16^i，k*G = Σ baseTable[i][ki]，
+// 每次取表都读出整行，用 cmov 选出需要的点
+func baseMult(k *scalar) (x, y *big.Int) {
+	acc := baseMultPoint(k)
+	return acc.big()
+}
+
+// baseMultPoint 计算 k*G，结果为射影坐标
+func baseMultPoint(k *scalar) projectivePoint {
+	baseTableOnce.Do(initBaseTable)
+	acc := projectivePoint{y: fieldOne}
+	for i := 0; i < 64; i++ {
+		digit := (k[i/16] >> (4 * uint(i%16))) & 15
+		var t projectivePoint
+		for j := 0; j < 16; j++ {
+			// digit == j 时 d 为0
+			d := digit ^ uint64(j)
+			t.cmov(&baseTable[i][j], ((d-1)>>63)&1)
+		}
+		acc.add(&acc, &t)
+	}
+	return acc
+}
+
+// ScalarBaseMult 计算 k*G，k 会先规约到 [0, N)
+// 和 Curve.ScalarBaseMult 结果相同，但运行时间不依赖 k，私钥和随机数都应该使用这个函数
+func ScalarBaseMult(k [32]byte) (x, y *big.Int) {
+	var s scalar
+	s.setBytes(&k)
+	return baseMult(&s)
+}
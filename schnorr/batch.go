@@ -0,0 +1,105 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// BatchItem 批量验签的一项
+type BatchItem struct {
+	PublicKey [33]byte
+	Message   []byte
+	Signature [64]byte
+}
+
+// batchTerm 解析后的一项，R 为y坐标是二次剩余的点
+type batchTerm struct {
+	ok   bool
+	P, R jacobianPoint
+	s, e *big.Int
+}
+
+// BatchVerify 批量验签，结果和对每一项调用 Verify 相同
+// 随机选取 a0 = 1, ai 为128位随机数，检查 (Σ ai*si)*G - Σ ai*Ri - Σ (ai*ei)*Pi 是无穷远点
+// 所有的点乘用一次多标量乘法完成
+// 失败时用二分法找出第一个验签失败的序号，成功时序号为 -1
+func BatchVerify(items []BatchItem) (bool, int) {
+	terms := make([]batchTerm, len(items))
+	for i := range items {
+		terms[i] = parseBatchItem(&items[i])
+	}
+	if batchVerify(terms) {
+		return true, -1
+	}
+	lo, hi := 0, len(terms)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if !batchVerify(terms[lo:mid]) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return false, lo
+}
+
+func parseBatchItem(item *BatchItem) (t batchTerm) {
+	P, ok := decompress(item.PublicKey[:])
+	if !ok {
+		return t
+	}
+	var rx fieldElement
+	if !rx.setBytes(item.Signature[:32]) {
+		return t
+	}
+	R, ok := liftX(&rx)
+	if !ok {
+		return t
+	}
+	s := new(big.Int).SetBytes(item.Signature[32:])
+	if s.Cmp(Curve.N) >= 0 {
+		return t
+	}
+	return batchTerm{
+		ok: true,
+		P:  P,
+		R:  R,
+		s:  s,
+		e:  challenge(item.Signature[:32], item.PublicKey[:], item.Message),
+	}
+}
+
+func batchVerify(terms []batchTerm) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	N := Curve.N
+	random := make([]byte, 16*len(terms))
+	if _, err := rand.Read(random); err != nil {
+		return false
+	}
+
+	points := make([]jacobianPoint, 0, 2*len(terms)+1)
+	scalars := make([]*big.Int, 0, 2*len(terms)+1)
+	sum := new(big.Int)
+	for i, t := range terms {
+		if !t.ok {
+			return false
+		}
+		a := big.NewInt(1)
+		if i > 0 {
+			a.SetBytes(random[16*i : 16*i+16])
+		}
+		sum.Add(sum, new(big.Int).Mul(a, t.s))
+
+		var negR, negP jacobianPoint
+		points = append(points, *negR.neg(&t.R), *negP.neg(&t.P))
+		ae := new(big.Int).Mul(a, t.e)
+		scalars = append(scalars, a, ae.Mod(ae, N))
+	}
+	points = append(points, basePoint())
+	scalars = append(scalars, sum.Mod(sum, N))
+
+	result := multiScalarMult(points, scalars)
+	return result.isInfinity()
+}
@@ -0,0 +1,105 @@
+package schnorr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func genBatchItems(n int) []BatchItem {
+	items := make([]BatchItem, n)
+	for i := range items {
+		d, P := GenKey()
+		message := []byte(fmt.Sprintf("test msg %d", i))
+		k0, R, err := GenNonce(d, message, nil)
+		if err != nil {
+			panic(err)
+		}
+		Rx, _, s, err := Sign(message, &PrivateKey{D: d, K0: k0}, []*PublicKey{{P: P, R: R}})
+		if err != nil {
+			panic(err)
+		}
+		items[i].PublicKey = P
+		items[i].Message = message
+		copy(items[i].Signature[:32], IntToByte(Rx))
+		copy(items[i].Signature[32:], IntToByte(s))
+	}
+	return items
+}
+
+func TestBatchVerify(t *testing.T) {
+	items := genBatchItems(20)
+	if ret, index := BatchVerify(items); !ret || index != -1 {
+		t.Fatal("batch verification failed", index)
+	}
+	if ret, index := BatchVerify(nil); !ret || index != -1 {
+		t.Fatal("empty batch should pass")
+	}
+
+	for _, bad := range []int{0, 7, 19} {
+		corrupted := append([]BatchItem{}, items...)
+		corrupted[bad].Message = []byte("other msg")
+		ret, index := BatchVerify(corrupted)
+		if ret || index != bad {
+			t.Fatalf("expected failure at %d, got %v %d", bad, ret, index)
+		}
+	}
+
+	// 第一个失败的序号
+	corrupted := append([]BatchItem{}, items...)
+	corrupted[5].Signature[63] ^= 1
+	corrupted[12].PublicKey = items[13].PublicKey
+	if ret, index := BatchVerify(corrupted); ret || index != 5 {
+		t.Fatal("expected failure at 5", index)
+	}
+
+	// 格式错误
+	corrupted = append([]BatchItem{}, items...)
+	for i := range corrupted[3].Signature[32:] {
+		corrupted[3].Signature[32+i] = 0xff
+	}
+	if ret, index := BatchVerify(corrupted); ret || index != 3 {
+		t.Fatal("expected failure at 3", index)
+	}
+}
+
+// TestBatchVerifyMatchesVerify 随机修改签名，BatchVerify 的结果和 Verify 一致
+func TestBatchVerifyMatchesVerify(t *testing.T) {
+	items := genBatchItems(8)
+	for i := range items {
+		items[i].Signature[i%32] ^= 1
+		expected, _ := Verify(items[i].PublicKey, items[i].Message, items[i].Signature)
+		ret, _ := BatchVerify(items[i : i+1])
+		if ret != expected {
+			t.Fatal("BatchVerify does not match Verify")
+		}
+	}
+}
+
+func benchmarkVerify(b *testing.B, n int) {
+	items := genBatchItems(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			if ret, _ := Verify(item.PublicKey, item.Message, item.Signature); !ret {
+				b.Fatal("verification failed")
+			}
+		}
+	}
+}
+
+func benchmarkBatchVerify(b *testing.B, n int) {
+	items := genBatchItems(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ret, _ := BatchVerify(items); !ret {
+			b.Fatal("verification failed")
+		}
+	}
+}
+
+func BenchmarkVerify10(b *testing.B)        { benchmarkVerify(b, 10) }
+func BenchmarkVerify100(b *testing.B)       { benchmarkVerify(b, 100) }
+func BenchmarkVerify1000(b *testing.B)      { benchmarkVerify(b, 1000) }
+func BenchmarkBatchVerify10(b *testing.B)   { benchmarkBatchVerify(b, 10) }
+func BenchmarkBatchVerify100(b *testing.B)  { benchmarkBatchVerify(b, 100) }
+func BenchmarkBatchVerify1000(b *testing.B) { benchmarkBatchVerify(b, 1000) }
@@ -0,0 +1,114 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	bip340AuxTag       = "BIP0340/aux"
+	bip340NonceTag     = "BIP0340/nonce"
+	bip340ChallengeTag = "BIP0340/challenge"
+)
+
+// XOnlyPublicKey BIP-340 使用的 32 字节公钥，只有 x 坐标，对应 y 为偶数的点
+type XOnlyPublicKey [32]byte
+
+// ToXOnly 把 33 字节压缩公钥转成 x-only 公钥
+// P 的 y 为奇数时，得到的是 -P，对应的私钥为 N-d，SignBIP340 会自动处理
+func ToXOnly(publicKey [33]byte) (XOnlyPublicKey, error) {
+	var xonly XOnlyPublicKey
+	if _, _, err := parsePublicKey(publicKey); err != nil {
+		return xonly, err
+	}
+	copy(xonly[:], publicKey[1:])
+	return xonly, nil
+}
+
+// Compressed x-only 公钥对应的 33 字节压缩公钥，y 为偶数
+func (pk XOnlyPublicKey) Compressed() [33]byte {
+	var P [33]byte
+	P[0] = 2
+	copy(P[1:], pk[:])
+	return P
+}
+
+// XOnlyFromPrivateKey 私钥 d 对应的 x-only 公钥
+func XOnlyFromPrivateKey(d [32]byte) (XOnlyPublicKey, error) {
+	var xonly XOnlyPublicKey
+	if err := checkScalar(d); err != nil {
+		return xonly, fmt.Errorf("%w: invalid private key", err)
+	}
+	Px, _ := ScalarBaseMult(d)
+	copy(xonly[:], IntToByte(Px))
+	return xonly, nil
+}
+
+// SignBIP340 按照 BIP-340 签名，结果可以被 Bitcoin Taproot、Nostr 等验证
+// aux 是32字节的随机数，用于防御侧信道攻击，全零时签名是确定的
+// message 可以是任意长度
+func SignBIP340(d [32]byte, message []byte, aux [32]byte) (signature [64]byte, err error) {
+	if err := checkScalar(d); err != nil {
+		return signature, fmt.Errorf("%w: invalid private key", err)
+	}
+	// P 的 y 为奇数时使用 N-d，d 和 k 只参与固定时间的运算
+	var x scalar
+	x.setBytes(&d)
+	Px, Py := baseMult(&x)
+	x.condNeg(uint64(Py.Bit(0)))
+	dBytes := x.bytes()
+	pk := IntToByte(Px)
+
+	// t = d xor TaggedHash("BIP0340/aux", aux)
+	t := TaggedHash(bip340AuxTag, aux[:])
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+	h := TaggedHash(bip340NonceTag, t[:], pk, message)
+	var k scalar
+	k.setBytes(&h)
+	if k.isZero() == 1 {
+		return signature, fmt.Errorf("%w: invalid nonce", ErrScalarOutOfRange)
+	}
+	kBytes := k.bytes()
+	Rx, Ry := baseMult(&k)
+	r := IntToByte(Rx)
+	e := bip340Challenge(r, pk, message)
+	s := response(&kBytes, &dBytes, Ry.Bit(0) == 1, e)
+
+	copy(signature[:32], r)
+	copy(signature[32:], IntToByte(s))
+	var xonly XOnlyPublicKey
+	copy(xonly[:], pk)
+	if ret, _ := VerifyBIP340(xonly, message, signature); !ret {
+		return [64]byte{}, ErrVerificationFailed
+	}
+	return signature, nil
+}
+
+// VerifyBIP340 按照 BIP-340 验签
+// R = s*G - e*P, e = TaggedHash("BIP0340/challenge", r||P||m) mod N，要求 R 的 y 为偶数且 Rx == r
+func VerifyBIP340(publicKey XOnlyPublicKey, message []byte, signature [64]byte) (bool, error) {
+	P := publicKey.Compressed()
+	Pj, ok := decompress(P[:])
+	if !ok {
+		return false, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	r, s, err := parseSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	e := bip340Challenge(signature[:32], publicKey[:], message)
+	Rx, Ry, ok := verifyPoint(s, e, &Pj)
+	if !ok || Ry.isOdd() || Rx.big().Cmp(r) != 0 {
+		return false, ErrVerificationFailed
+	}
+	return true, nil
+}
+
+func bip340Challenge(r []byte, pk []byte, message []byte) *big.Int {
+	h := TaggedHash(bip340ChallengeTag, r, pk, message)
+	e := new(big.Int).SetBytes(h[:])
+	return e.Mod(e, Curve.N)
+}
@@ -0,0 +1,81 @@
+package schnorr
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"os"
+	"testing"
+)
+
+// TestBIP340Vectors 官方测试向量 https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv
+func TestBIP340Vectors(t *testing.T) {
+	f, err := os.Open("testdata/bip-0340-test-vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records[1:] {
+		index, comment := record[0], record[7]
+		var pk XOnlyPublicKey
+		pkBytes, _ := hex.DecodeString(record[2])
+		copy(pk[:], pkBytes)
+		message, _ := hex.DecodeString(record[4])
+		var signature [64]byte
+		sigBytes, _ := hex.DecodeString(record[5])
+		copy(signature[:], sigBytes)
+		expected := record[6] == "TRUE"
+
+		if record[1] != "" {
+			var d, aux [32]byte
+			dBytes, _ := hex.DecodeString(record[1])
+			copy(d[:], dBytes)
+			auxBytes, _ := hex.DecodeString(record[3])
+			copy(aux[:], auxBytes)
+
+			xonly, err := XOnlyFromPrivateKey(d)
+			if err != nil || xonly != pk {
+				t.Fatalf("case %s: public key mismatch", index)
+			}
+			sig, err := SignBIP340(d, message, aux)
+			if err != nil {
+				t.Fatalf("case %s: %v", index, err)
+			}
+			if sig != signature {
+				t.Fatalf("case %s: got %x", index, sig)
+			}
+		}
+		ret, _ := VerifyBIP340(pk, message, signature)
+		if ret != expected {
+			t.Fatalf("case %s: expected %v, %s", index, expected, comment)
+		}
+	}
+}
+
+func TestToXOnly(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		d, P := GenKey()
+		xonly, err := ToXOnly(P)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := XOnlyFromPrivateKey(d)
+		if xonly != expected {
+			t.Fatal("x-only public key mismatch")
+		}
+		message := []byte("test msg")
+		signature, err := SignBIP340(d, message, [32]byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret, _ := VerifyBIP340(xonly, message, signature); !ret {
+			t.Fatal("signature verification failed")
+		}
+		if P[0] == 2 && xonly.Compressed() != P {
+			t.Fatal("compressed public key mismatch")
+		}
+	}
+}
@@ -44,15 +44,18 @@ func Marshal(curve elliptic.Curve, x, y *big.Int) []byte {
 // error, x = nil.
 func Unmarshal(curve elliptic.Curve, data []byte) (x, y *big.Int) {
 	byteLen := (curve.Params().BitSize + 7) >> 3
-	if (data[0] &^ 1) != 2 {
+	if len(data) != 1+byteLen {
 		return
 	}
-	if len(data) != 1+byteLen {
+	if (data[0] &^ 1) != 2 {
 		return
 	}
 
 	x0 := new(big.Int).SetBytes(data[1 : 1+byteLen])
 	P := curve.Params().P
+	if x0.Cmp(P) >= 0 {
+		return
+	}
 	ySq := new(big.Int)
 	ySq.Exp(x0, Three, P)
 	ySq.Add(ySq, Seven)
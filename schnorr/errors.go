@@ -0,0 +1,27 @@
+package schnorr
+
+import "errors"
+
+// 包内所有错误都由下面的错误包装而来，调用方用 errors.Is 判断错误类型
+var (
+	// ErrInvalidPoint 公钥、随机数点或者签名中的 r 不是合法的曲线点
+	ErrInvalidPoint = errors.New("invalid point")
+	// ErrScalarOutOfRange 私钥、随机数或者签名中的 s 不在 [1, N) 或 [0, N) 内
+	ErrScalarOutOfRange = errors.New("scalar out of range")
+	// ErrInvalidLength 输入的长度不对或者为空
+	ErrInvalidLength = errors.New("invalid length")
+	// ErrSizeMismatch 几个输入的数量对不上
+	ErrSizeMismatch = errors.New("size mismatch")
+	// ErrInvalidIndex 序号越界
+	ErrInvalidIndex = errors.New("invalid index")
+	// ErrDuplicateIndex 序号重复
+	ErrDuplicateIndex = errors.New("duplicate index")
+	// ErrKeyNotInSet 私钥对应的公钥不在公钥集合中
+	ErrKeyNotInSet = errors.New("privateKey is not in array")
+	// ErrAlreadySigned 参与者已经签过名
+	ErrAlreadySigned = errors.New("already signed")
+	// ErrInvalidBitmap 子集位图不合法或者和公钥集合不一致
+	ErrInvalidBitmap = errors.New("invalid bitmap")
+	// ErrVerificationFailed 签名、预签名或者证明验证失败
+	ErrVerificationFailed = errors.New("signature verification failed")
+)
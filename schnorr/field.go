@@ -0,0 +1,254 @@
+package schnorr
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fieldElement secp256k1 的域元素 mod p, p = 2^256 - 2^32 - 977
+// 4个64位的limb，小端序，总是保持 < p
+// 所有运算不依赖数值分支，运行时间固定
+type fieldElement [4]uint64
+
+// fieldR = 2^256 mod p = 2^32 + 977
+const fieldR = 0x1000003D1
+
+var (
+	fieldP     = fieldElement{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+	fieldOne   = fieldElement{1, 0, 0, 0}
+	fieldSeven = fieldElement{7, 0, 0, 0}
+)
+
+// setBytes 由32字节大端序设置，返回是否 < p
+func (f *fieldElement) setBytes(b []byte) bool {
+	for i := 0; i < 4; i++ {
+		f[i] = uint64(b[31-8*i]) | uint64(b[30-8*i])<<8 | uint64(b[29-8*i])<<16 | uint64(b[28-8*i])<<24 |
+			uint64(b[27-8*i])<<32 | uint64(b[26-8*i])<<40 | uint64(b[25-8*i])<<48 | uint64(b[24-8*i])<<56
+	}
+	_, borrow := f.subP()
+	return borrow == 1
+}
+
+// setBig 由 big.Int 设置，i 必须在 [0, p) 之间
+func (f *fieldElement) setBig(i *big.Int) *fieldElement {
+	f.setBytes(IntToByte(i))
+	return f
+}
+
+// bytes 32字节大端序
+func (f *fieldElement) bytes() (b [32]byte) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-8*i-j] = byte(f[i] >> (8 * j))
+		}
+	}
+	return b
+}
+
+func (f *fieldElement) big() *big.Int {
+	b := f.bytes()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// subP 计算 f - p，borrow 为1表示 f < p
+func (f *fieldElement) subP() (r fieldElement, borrow uint64) {
+	r[0], borrow = bits.Sub64(f[0], fieldP[0], 0)
+	r[1], borrow = bits.Sub64(f[1], fieldP[1], borrow)
+	r[2], borrow = bits.Sub64(f[2], fieldP[2], borrow)
+	r[3], borrow = bits.Sub64(f[3], fieldP[3], borrow)
+	return r, borrow
+}
+
+// reduce 在 f < 2^256 时把 f 规约到 [0, p)，carry 为1表示实际的值还要再加 2^256
+func (f *fieldElement) reduce(carry uint64) {
+	// t = f + 2^256 - p = f - p mod 2^256
+	var t fieldElement
+	var c uint64
+	t[0], c = bits.Add64(f[0], fieldR, 0)
+	t[1], c = bits.Add64(f[1], 0, c)
+	t[2], c = bits.Add64(f[2], 0, c)
+	t[3], c = bits.Add64(f[3], 0, c)
+	f.cmov(&t, carry|c)
+}
+
+// cmov flag 为1时 f = a，否则不变
+func (f *fieldElement) cmov(a *fieldElement, flag uint64) {
+	mask := -flag
+	f[0] ^= mask & (f[0] ^ a[0])
+	f[1] ^= mask & (f[1] ^ a[1])
+	f[2] ^= mask & (f[2] ^ a[2])
+	f[3] ^= mask & (f[3] ^ a[3])
+}
+
+func (f *fieldElement) isZero() bool {
+	return f[0]|f[1]|f[2]|f[3] == 0
+}
+
+func (f *fieldElement) equal(a *fieldElement) bool {
+	return (f[0]^a[0])|(f[1]^a[1])|(f[2]^a[2])|(f[3]^a[3]) == 0
+}
+
+func (f *fieldElement) isOdd() bool {
+	return f[0]&1 == 1
+}
+
+// add f = a + b
+func (f *fieldElement) add(a, b *fieldElement) *fieldElement {
+	var c uint64
+	f[0], c = bits.Add64(a[0], b[0], 0)
+	f[1], c = bits.Add64(a[1], b[1], c)
+	f[2], c = bits.Add64(a[2], b[2], c)
+	f[3], c = bits.Add64(a[3], b[3], c)
+	f.reduce(c)
+	return f
+}
+
+// sub f = a - b
+func (f *fieldElement) sub(a, b *fieldElement) *fieldElement {
+	var borrow uint64
+	f[0], borrow = bits.Sub64(a[0], b[0], 0)
+	f[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	f[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	f[3], borrow = bits.Sub64(a[3], b[3], borrow)
+	// 借位时加上 p，即减去 2^256 - p
+	f[0], borrow = bits.Sub64(f[0], fieldR&-borrow, 0)
+	f[1], borrow = bits.Sub64(f[1], 0, borrow)
+	f[2], borrow = bits.Sub64(f[2], 0, borrow)
+	f[3], _ = bits.Sub64(f[3], 0, borrow)
+	return f
+}
+
+// neg f = -a
+func (f *fieldElement) neg(a *fieldElement) *fieldElement {
+	var zero fieldElement
+	return f.sub(&zero, a)
+}
+
+// mul f = a * b
+func (f *fieldElement) mul(a, b *fieldElement) *fieldElement {
+	var t [8]uint64
+	var c uint64
+	c, t[0] = mac(a[0], b[0], 0, 0)
+	c, t[1] = mac(a[0], b[1], 0, c)
+	c, t[2] = mac(a[0], b[2], 0, c)
+	t[4], t[3] = mac(a[0], b[3], 0, c)
+
+	c, t[1] = mac(a[1], b[0], t[1], 0)
+	c, t[2] = mac(a[1], b[1], t[2], c)
+	c, t[3] = mac(a[1], b[2], t[3], c)
+	t[5], t[4] = mac(a[1], b[3], t[4], c)
+
+	c, t[2] = mac(a[2], b[0], t[2], 0)
+	c, t[3] = mac(a[2], b[1], t[3], c)
+	c, t[4] = mac(a[2], b[2], t[4], c)
+	t[6], t[5] = mac(a[2], b[3], t[5], c)
+
+	c, t[3] = mac(a[3], b[0], t[3], 0)
+	c, t[4] = mac(a[3], b[1], t[4], c)
+	c, t[5] = mac(a[3], b[2], t[5], c)
+	t[7], t[6] = mac(a[3], b[3], t[6], c)
+
+	f.reduceWide(&t)
+	return f
+}
+
+// mac 计算 a*b + c + carry，结果不会超过128位
+func mac(a, b, c, carry uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(a, b)
+	var cc uint64
+	lo, cc = bits.Add64(lo, c, 0)
+	hi += cc
+	lo, cc = bits.Add64(lo, carry, 0)
+	hi += cc
+	return hi, lo
+}
+
+// square f = a * a
+func (f *fieldElement) square(a *fieldElement) *fieldElement {
+	return f.mul(a, a)
+}
+
+// reduceWide 把512位的 t 规约到 [0, p)，利用 2^256 = 2^32 + 977 mod p
+func (f *fieldElement) reduceWide(t *[8]uint64) {
+	// r = t[0:4] + t[4:8] * R，结果不超过 2^256 * 2^34
+	var r fieldElement
+	var carry uint64
+	carry, r[0] = mac(t[4], fieldR, t[0], 0)
+	carry, r[1] = mac(t[5], fieldR, t[1], carry)
+	carry, r[2] = mac(t[6], fieldR, t[2], carry)
+	carry, r[3] = mac(t[7], fieldR, t[3], carry)
+	// r = r + carry * R
+	hi, lo := bits.Mul64(carry, fieldR)
+	var c uint64
+	r[0], c = bits.Add64(r[0], lo, 0)
+	r[1], c = bits.Add64(r[1], hi, c)
+	r[2], c = bits.Add64(r[2], 0, c)
+	r[3], c = bits.Add64(r[3], 0, c)
+	// 再次溢出时 r 已经很小，加上 R 不会再溢出
+	r[0], c = bits.Add64(r[0], fieldR&-c, 0)
+	r[1], c = bits.Add64(r[1], 0, c)
+	r[2], c = bits.Add64(r[2], 0, c)
+	r[3], _ = bits.Add64(r[3], 0, c)
+	r.reduce(0)
+	*f = r
+}
+
+// squareN f = a^(2^n)
+func (f *fieldElement) squareN(a *fieldElement, n int) *fieldElement {
+	*f = *a
+	for i := 0; i < n; i++ {
+		f.square(f)
+	}
+	return f
+}
+
+// powChain 计算 p-2 和 (p+1)/4 共同的前缀 x223 = a^(2^223-1)，以及中间结果 x2, x22
+// xN 表示 a^(2^N-1)，加法链和 libsecp256k1 相同
+func powChain(a *fieldElement) (x2, x22, x223 fieldElement) {
+	var x3, x6, x9, x11, x44, x88, x176, x220, t fieldElement
+	x2.square(a)
+	x2.mul(&x2, a)
+	x3.square(&x2)
+	x3.mul(&x3, a)
+	x6.mul(t.squareN(&x3, 3), &x3)
+	x9.mul(t.squareN(&x6, 3), &x3)
+	x11.mul(t.squareN(&x9, 2), &x2)
+	x22.mul(t.squareN(&x11, 11), &x11)
+	x44.mul(t.squareN(&x22, 22), &x22)
+	x88.mul(t.squareN(&x44, 44), &x44)
+	x176.mul(t.squareN(&x88, 88), &x88)
+	x220.mul(t.squareN(&x176, 44), &x44)
+	x223.mul(t.squareN(&x220, 3), &x3)
+	return x2, x22, x223
+}
+
+// inv f = a^(p-2) = 1/a，a 为0时结果为0
+func (f *fieldElement) inv(a *fieldElement) *fieldElement {
+	x2, x22, x223 := powChain(a)
+	var t fieldElement
+	t.mul(t.squareN(&x223, 23), &x22)
+	t.mul(t.squareN(&t, 5), a)
+	t.mul(t.squareN(&t, 3), &x2)
+	t.mul(t.squareN(&t, 2), a)
+	*f = t
+	return f
+}
+
+// sqrt f = a^((p+1)/4)，返回 a 是否是二次剩余
+// 由于 (p+1)/4 是偶数，得到的平方根本身也是二次剩余
+func (f *fieldElement) sqrt(a *fieldElement) bool {
+	x2, x22, x223 := powChain(a)
+	var r, check fieldElement
+	r.mul(r.squareN(&x223, 23), &x22)
+	r.mul(r.squareN(&r, 6), &x2)
+	r.squareN(&r, 2)
+	check.square(&r)
+	*f = r
+	return check.equal(a)
+}
+
+// isSquare 判断 a 是否是二次剩余(或者0)
+func (f *fieldElement) isSquare() bool {
+	var r fieldElement
+	return r.sqrt(f)
+}
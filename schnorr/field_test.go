@@ -0,0 +1,69 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randField(t *testing.T) (*fieldElement, *big.Int) {
+	i, err := rand.Int(rand.Reader, Curve.P)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(fieldElement).setBig(i), i
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	P := Curve.P
+	edge := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(P, One), new(big.Int).Sub(P, Two),
+		new(big.Int).Lsh(One, 255), new(big.Int).Sub(new(big.Int).Lsh(One, 256), new(big.Int).Lsh(One, 33))}
+	var values []*big.Int
+	values = append(values, edge...)
+	for i := 0; i < 50; i++ {
+		_, b := randField(t)
+		values = append(values, b)
+	}
+	for _, x := range values {
+		for _, y := range values {
+			a := new(fieldElement).setBig(x)
+			b := new(fieldElement).setBig(y)
+			check := func(op string, got *fieldElement, expected *big.Int) {
+				expected.Mod(expected, P)
+				if got.big().Cmp(expected) != 0 {
+					t.Fatalf("%s(%x, %x) = %x, expected %x", op, x, y, got.big(), expected)
+				}
+			}
+			check("add", new(fieldElement).add(a, b), new(big.Int).Add(x, y))
+			check("sub", new(fieldElement).sub(a, b), new(big.Int).Sub(x, y))
+			check("mul", new(fieldElement).mul(a, b), new(big.Int).Mul(x, y))
+		}
+		a := new(fieldElement).setBig(x)
+		check := new(fieldElement).neg(a)
+		if check.big().Cmp(new(big.Int).Mod(new(big.Int).Neg(x), P)) != 0 {
+			t.Fatal("neg failed")
+		}
+		if x.Sign() != 0 {
+			inv := new(fieldElement).inv(a)
+			if inv.big().Cmp(new(big.Int).ModInverse(x, P)) != 0 {
+				t.Fatal("inv failed")
+			}
+		}
+		var root fieldElement
+		ok := root.sqrt(a)
+		if ok != (big.Jacobi(x, P) >= 0) {
+			t.Fatal("sqrt failed")
+		}
+		if ok && x.Sign() != 0 && big.Jacobi(root.big(), P) != 1 {
+			t.Fatal("sqrt is not a quadratic residue")
+		}
+	}
+}
+
+func BenchmarkFieldMul(b *testing.B) {
+	x, _ := rand.Int(rand.Reader, Curve.P)
+	f := new(fieldElement).setBig(x)
+	for i := 0; i < b.N; i++ {
+		f.mul(f, f)
+	}
+}
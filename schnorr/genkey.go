@@ -2,23 +2,20 @@ package schnorr
 
 import (
 	"crypto/rand"
-	"math/big"
 )
 
 func GenKey()([32]byte, [33]byte) {
 	var privateKey [32]byte
 	var publicKey [33]byte
-	d := new(big.Int)
+	var d scalar
 	for {
 		rand.Read(privateKey[:])
-		d.SetBytes(privateKey[:])
-		d.Mod(d, Curve.N)
-		if d.Cmp(Zero) != 0{
+		if d.setBytes(&privateKey)|d.isZero() == 0 {
 			break
 		}
 	}
 
-	Px, Py := Curve.ScalarBaseMult(d.Bytes())
+	Px, Py := baseMult(&d)
 	P := Marshal(Curve, Px, Py)
 	copy(publicKey[:], P)
 
@@ -0,0 +1,192 @@
+package schnorr
+
+import "math/big"
+
+// jacobianPoint Jacobian 坐标的点 (X/Z^2, Y/Z^3)，Z 为0表示无穷远点
+type jacobianPoint struct {
+	x, y, z fieldElement
+}
+
+// setAffine 由仿射坐标设置，(0, 0) 表示无穷远点
+func (p *jacobianPoint) setAffine(x, y *fieldElement) *jacobianPoint {
+	p.x, p.y = *x, *y
+	p.z = fieldOne
+	if x.isZero() && y.isZero() {
+		p.z = fieldElement{}
+	}
+	return p
+}
+
+// setBig 由 big.Int 表示的仿射坐标设置，和 Curve 一样用 (0, 0) 表示无穷远点
+func (p *jacobianPoint) setBig(x, y *big.Int) *jacobianPoint {
+	var fx, fy fieldElement
+	fx.setBig(x)
+	fy.setBig(y)
+	return p.setAffine(&fx, &fy)
+}
+
+func (p *jacobianPoint) isInfinity() bool {
+	return p.z.isZero()
+}
+
+// affine 转换成仿射坐标，无穷远点返回 (0, 0)
+func (p *jacobianPoint) affine() (x, y fieldElement) {
+	if p.isInfinity() {
+		return x, y
+	}
+	var zInv, zInv2 fieldElement
+	zInv.inv(&p.z)
+	zInv2.square(&zInv)
+	x.mul(&p.x, &zInv2)
+	zInv2.mul(&zInv2, &zInv)
+	y.mul(&p.y, &zInv2)
+	return x, y
+}
+
+// big 转换成 big.Int 表示的仿射坐标
+func (p *jacobianPoint) big() (x, y *big.Int) {
+	fx, fy := p.affine()
+	return fx.big(), fy.big()
+}
+
+// neg p = -a
+func (p *jacobianPoint) neg(a *jacobianPoint) *jacobianPoint {
+	p.x = a.x
+	p.y.neg(&a.y)
+	p.z = a.z
+	return p
+}
+
+// double p = 2a (dbl-2009-l)
+func (p *jacobianPoint) double(a *jacobianPoint) *jacobianPoint {
+	if a.isInfinity() || a.y.isZero() {
+		*p = jacobianPoint{}
+		return p
+	}
+	var A, B, C, D, E, F, t fieldElement
+	A.square(&a.x)
+	B.square(&a.y)
+	C.square(&B)
+	// D = 2*((X+B)^2 - A - C)
+	D.add(&a.x, &B)
+	D.square(&D)
+	D.sub(&D, &A)
+	D.sub(&D, &C)
+	D.add(&D, &D)
+	// E = 3*A, F = E^2
+	E.add(&A, &A)
+	E.add(&E, &A)
+	F.square(&E)
+	// Z3 = 2*Y*Z
+	var z3 fieldElement
+	z3.mul(&a.y, &a.z)
+	z3.add(&z3, &z3)
+	// X3 = F - 2*D
+	var x3 fieldElement
+	x3.sub(&F, &D)
+	x3.sub(&x3, &D)
+	// Y3 = E*(D - X3) - 8*C
+	var y3 fieldElement
+	y3.sub(&D, &x3)
+	y3.mul(&E, &y3)
+	t.add(&C, &C)
+	t.add(&t, &t)
+	t.add(&t, &t)
+	y3.sub(&y3, &t)
+	p.x, p.y, p.z = x3, y3, z3
+	return p
+}
+
+// add p = a + b
+// U1 = X1*Z2^2, U2 = X2*Z1^2, S1 = Y1*Z2^3, S2 = Y2*Z1^3, H = U2-U1, r = S2-S1
+// X3 = r^2 - H^3 - 2*U1*H^2, Y3 = r*(U1*H^2 - X3) - S1*H^3, Z3 = Z1*Z2*H
+func (p *jacobianPoint) add(a, b *jacobianPoint) *jacobianPoint {
+	if a.isInfinity() {
+		*p = *b
+		return p
+	}
+	if b.isInfinity() {
+		*p = *a
+		return p
+	}
+	var z1z1, z2z2, u1, u2, s1, s2, h, r fieldElement
+	// b 是仿射坐标(Z2 = 1)时省去 U1, S1 的计算
+	affine := b.z.equal(&fieldOne)
+	z1z1.square(&a.z)
+	if affine {
+		u1, s1 = a.x, a.y
+	} else {
+		z2z2.square(&b.z)
+		u1.mul(&a.x, &z2z2)
+		s1.mul(&a.y, &b.z)
+		s1.mul(&s1, &z2z2)
+	}
+	u2.mul(&b.x, &z1z1)
+	s2.mul(&b.y, &a.z)
+	s2.mul(&s2, &z1z1)
+	h.sub(&u2, &u1)
+	r.sub(&s2, &s1)
+	if h.isZero() {
+		if r.isZero() {
+			return p.double(a)
+		}
+		*p = jacobianPoint{}
+		return p
+	}
+	var h2, h3, u1h2, x3, y3, z3 fieldElement
+	h2.square(&h)
+	h3.mul(&h2, &h)
+	u1h2.mul(&u1, &h2)
+	x3.square(&r)
+	x3.sub(&x3, &h3)
+	x3.sub(&x3, &u1h2)
+	x3.sub(&x3, &u1h2)
+	y3.sub(&u1h2, &x3)
+	y3.mul(&y3, &r)
+	s1.mul(&s1, &h3)
+	y3.sub(&y3, &s1)
+	z3.mul(&a.z, &h)
+	if !affine {
+		z3.mul(&z3, &b.z)
+	}
+	p.x, p.y, p.z = x3, y3, z3
+	return p
+}
+
+// liftX 由x坐标恢复y坐标为二次剩余的点，x 不在曲线上时返回 false
+func liftX(x *fieldElement) (p jacobianPoint, ok bool) {
+	var y2, y fieldElement
+	y2.square(x)
+	y2.mul(&y2, x)
+	y2.add(&y2, &fieldSeven)
+	if !y.sqrt(&y2) {
+		return p, false
+	}
+	p.setAffine(x, &y)
+	return p, true
+}
+
+// decompress 解析33字节压缩公钥，要求 x < p 且在曲线上
+func decompress(b []byte) (p jacobianPoint, ok bool) {
+	if len(b) != 33 || (b[0] != 2 && b[0] != 3) {
+		return p, false
+	}
+	var x fieldElement
+	if !x.setBytes(b[1:]) {
+		return p, false
+	}
+	p, ok = liftX(&x)
+	if !ok {
+		return p, false
+	}
+	if p.y.isOdd() != (b[0] == 3) {
+		p.y.neg(&p.y)
+	}
+	return p, true
+}
+
+// basePoint 生成元 G
+func basePoint() jacobianPoint {
+	var p jacobianPoint
+	return *p.setBig(Curve.Gx, Curve.Gy)
+}
@@ -0,0 +1,110 @@
+package schnorr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+const halfAggTag = "schnorr-go/halfagg-randomizer"
+
+// HalfAggregate 把 n 个不同签名者对不同消息的签名压缩成 32*n + 32 字节，不需要签名者参与
+// 结果为 r1||r2||...||rn||s，s = Σ zi*si mod N
+// z1 = 1，zi 由前 i 项的 (r, P, m) 计算，见 halfAggCoefficients
+// 不检查每个签名是否有效，聚合结果用 VerifyHalfAggregate 验证
+func HalfAggregate(items []BatchItem) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: empty items", ErrInvalidLength)
+	}
+	z := halfAggCoefficients(len(items), func(i int) ([]byte, []byte, []byte) {
+		return items[i].Signature[:32], items[i].PublicKey[:], items[i].Message
+	})
+	agg := make([]byte, 0, 32*len(items)+32)
+	s := new(big.Int)
+	for i := range items {
+		_, si, err := parseSignature(items[i].Signature)
+		if err != nil {
+			return nil, err
+		}
+		s.Add(s, si.Mul(si, z[i]))
+		agg = append(agg, items[i].Signature[:32]...)
+	}
+	s.Mod(s, Curve.N)
+	return append(agg, IntToByte(s)...), nil
+}
+
+// VerifyHalfAggregate 验证半聚合签名
+// 每个签名满足 si*G = Ri + ei*Pi，因此 s*G = Σ zi*(Ri + ei*Pi)，Ri 为 x 坐标是 ri 且 y 是二次剩余的点
+// 等式右边用一次多标量乘法计算
+func VerifyHalfAggregate(publicKeys [][33]byte, messages [][]byte, agg []byte) (bool, error) {
+	n := len(publicKeys)
+	if n == 0 {
+		return false, fmt.Errorf("%w: empty publicKeys", ErrInvalidLength)
+	}
+	if len(messages) != n {
+		return false, fmt.Errorf("%w: messages size is not equal to publicKeys", ErrSizeMismatch)
+	}
+	if len(agg) != 32*n+32 {
+		return false, fmt.Errorf("%w: invalid aggregate signature length", ErrInvalidLength)
+	}
+	s := new(big.Int).SetBytes(agg[32*n:])
+	if s.Cmp(Curve.N) >= 0 {
+		return false, fmt.Errorf("%w: s is larger than or equal to curve order", ErrScalarOutOfRange)
+	}
+	z := halfAggCoefficients(n, func(i int) ([]byte, []byte, []byte) {
+		return agg[32*i : 32*i+32], publicKeys[i][:], messages[i]
+	})
+
+	points := make([]jacobianPoint, 0, 2*n+1)
+	scalars := make([]*big.Int, 0, 2*n+1)
+	for i := 0; i < n; i++ {
+		P, ok := decompress(publicKeys[i][:])
+		if !ok {
+			return false, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+		}
+		var rx fieldElement
+		if !rx.setBytes(agg[32*i : 32*i+32]) {
+			return false, fmt.Errorf("%w: r is larger than or equal to field size", ErrInvalidPoint)
+		}
+		R, ok := liftX(&rx)
+		if !ok {
+			return false, ErrVerificationFailed
+		}
+		e := challenge(agg[32*i:32*i+32], publicKeys[i][:], messages[i])
+		e.Mul(e, z[i])
+		points = append(points, R, P)
+		scalars = append(scalars, z[i], e.Mod(e, Curve.N))
+	}
+	// Σ zi*Ri + Σ zi*ei*Pi - s*G = 0
+	G := basePoint()
+	G.neg(&G)
+	points = append(points, G)
+	scalars = append(scalars, s)
+	result := multiScalarMult(points, scalars)
+	if !result.isInfinity() {
+		return false, ErrVerificationFailed
+	}
+	return true, nil
+}
+
+// halfAggCoefficients 计算每个签名的系数 z1 = 1，zi = ci mod N
+// c1 = TaggedHash(tag, r1||P1||len(m1)||m1)，ci = TaggedHash(tag, ci-1||ri||Pi||len(mi)||mi)
+// len(m) 是8字节大端序的消息长度，ci 依赖前 i 项的全部内容
+func halfAggCoefficients(n int, item func(i int) (r, P, m []byte)) []*big.Int {
+	z := make([]*big.Int, n)
+	var c [32]byte
+	for i := 0; i < n; i++ {
+		r, P, m := item(i)
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(m)))
+		if i == 0 {
+			c = TaggedHash(halfAggTag, r, P, length[:], m)
+			z[i] = big.NewInt(1)
+			continue
+		}
+		c = TaggedHash(halfAggTag, c[:], r, P, length[:], m)
+		z[i] = new(big.Int).SetBytes(c[:])
+		z[i].Mod(z[i], Curve.N)
+	}
+	return z
+}
@@ -0,0 +1,59 @@
+package schnorr
+
+import "testing"
+
+func TestHalfAggregate(t *testing.T) {
+	items := genBatchItems(10)
+	agg, err := HalfAggregate(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(agg) != 32*len(items)+32 {
+		t.Fatal("invalid aggregate signature length")
+	}
+	var publicKeys [][33]byte
+	var messages [][]byte
+	for _, item := range items {
+		publicKeys = append(publicKeys, item.PublicKey)
+		messages = append(messages, item.Message)
+	}
+	if ret, err := VerifyHalfAggregate(publicKeys, messages, agg); err != nil || !ret {
+		t.Fatal("half aggregate verification failed", err)
+	}
+
+	// 只有一个签名时就是原来的签名
+	single, err := HalfAggregate(items[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(single) != string(items[0].Signature[:]) {
+		t.Fatal("single aggregate should equal the signature")
+	}
+
+	// 修改消息、交换顺序都会失败
+	messages[3] = []byte("other msg")
+	if ret, _ := VerifyHalfAggregate(publicKeys, messages, agg); ret {
+		t.Fatal("modified message should fail")
+	}
+	messages[3] = items[3].Message
+	publicKeys[1], publicKeys[2] = publicKeys[2], publicKeys[1]
+	messages[1], messages[2] = messages[2], messages[1]
+	if ret, _ := VerifyHalfAggregate(publicKeys, messages, agg); ret {
+		t.Fatal("reordered items should fail")
+	}
+
+	// 包含一个无效签名时聚合结果无效
+	items[5].Signature[63] ^= 1
+	agg, err = HalfAggregate(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeys, messages = nil, nil
+	for _, item := range items {
+		publicKeys = append(publicKeys, item.PublicKey)
+		messages = append(messages, item.Message)
+	}
+	if ret, _ := VerifyHalfAggregate(publicKeys, messages, agg); ret {
+		t.Fatal("aggregate with invalid signature should fail")
+	}
+}
@@ -0,0 +1,335 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// KeyAggContext 预先解析并聚合的公钥集合，参与者不变时可以在多次签名、验签之间复用
+// 创建时检查所有公钥，计算聚合系数和聚合公钥，之后每次签名只需要处理随机数 R
+// 创建后只读，可以被多个 goroutine 同时使用
+type KeyAggContext struct {
+	keys         [][33]byte
+	points       []jacobianPoint
+	coefficients []*big.Int
+	index        map[[33]byte][]int
+	o            *options
+	agg          *keyAggregator
+	aggregate    [33]byte
+	internal     [33]byte
+	publicKey    [33]byte
+	px, py       *big.Int
+	// WithTweak: negate 表示内部公钥的 y 为奇数，所有人的私钥取反
+	// tweak 是 WithPlainTweak 和 WithTweak 合起来的调整值，第一个参与者加上 e*tweak
+	negate bool
+	tweak  *big.Int
+}
+
+// NewKeyAggContext 解析 publicKeys 并计算聚合公钥，opts 和签名时的配置相同
+func NewKeyAggContext(publicKeys [][33]byte, opts ...Option) (*KeyAggContext, error) {
+	return newKeyAggContext(publicKeys, newOptions(opts))
+}
+
+func newKeyAggContext(publicKeys [][33]byte, o *options) (*KeyAggContext, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("%w: empty publicKeys", ErrInvalidLength)
+	}
+	if err := o.check(publicKeys); err != nil {
+		return nil, err
+	}
+	points, ok := decompressPoints(publicKeys)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	ctx := &KeyAggContext{
+		keys:   append([][33]byte{}, publicKeys...),
+		points: points,
+		index:  make(map[[33]byte][]int),
+		o:      o,
+		agg:    newKeyAggregator(publicKeys, o),
+	}
+	ctx.coefficients = ctx.agg.coefficients(publicKeys)
+	for i, publicKey := range publicKeys {
+		ctx.index[publicKey] = append(ctx.index[publicKey], i)
+	}
+	P := combine(points, ctx.coefficients)
+	if P.isInfinity() {
+		return nil, fmt.Errorf("%w: aggregate public key is infinity", ErrInvalidPoint)
+	}
+	ctx.aggregate = compress(&P)
+	if o.plainTweak != nil {
+		var t scalar
+		if t.setBytes(o.plainTweak) != 0 {
+			return nil, fmt.Errorf("%w: plain tweak is larger than or equal to curve order", ErrScalarOutOfRange)
+		}
+		ctx.tweak = t.big()
+		tG := straussMult(ctx.tweak, nil, nil)
+		P.add(&P, &tG)
+		if P.isInfinity() {
+			return nil, fmt.Errorf("%w: tweaked public key is infinity", ErrInvalidPoint)
+		}
+	}
+	ctx.internal = compress(&P)
+	if o.tweak != nil {
+		var t *big.Int
+		var err error
+		if P, t, ctx.negate, err = tweakPoint(&P, o.tweak.data); err != nil {
+			return nil, err
+		}
+		// Q = ±(P + t1*G) + t2*G，第一个参与者加上 e*(±t1 + t2)
+		if ctx.tweak == nil {
+			ctx.tweak = t
+		} else {
+			if ctx.negate {
+				ctx.tweak.Neg(ctx.tweak)
+			}
+			ctx.tweak.Add(ctx.tweak, t)
+			ctx.tweak.Mod(ctx.tweak, Curve.N)
+		}
+	}
+	ctx.publicKey = compress(&P)
+	ctx.px, ctx.py = P.big()
+	return ctx, nil
+}
+
+// PublicKey 聚合公钥，和 MultiVerify 使用的相同，设置了 WithTweak 时为调整后的 Q
+func (ctx *KeyAggContext) PublicKey() [33]byte {
+	return ctx.publicKey
+}
+
+// InternalKey Taproot 调整之前的公钥，可以作为 Taproot 内部公钥，没有设置 WithTweak 时和 PublicKey 相同
+// 设置了 WithPlainTweak 时已经加上了 t*G
+func (ctx *KeyAggContext) InternalKey() [33]byte {
+	return ctx.internal
+}
+
+// AggregateKey 没有任何调整的聚合公钥，是 hdkey.NewAggregateKey 派生子公钥的起点
+func (ctx *KeyAggContext) AggregateKey() [33]byte {
+	return ctx.aggregate
+}
+
+// PublicKeys 创建时传入的公钥
+func (ctx *KeyAggContext) PublicKeys() [][33]byte {
+	return append([][33]byte{}, ctx.keys...)
+}
+
+// Sign 一个参与者签名，nonces 是每个参与者的 R，和公钥一一对应
+// 返回自己的 R 和 s = k + e*a*d
+func (ctx *KeyAggContext) Sign(message []byte, privateKey *PrivateKey, nonces [][33]byte) (RIx, RIy, s *big.Int, err error) {
+	if err := checkPrivateKey(privateKey); err != nil {
+		return nil, nil, nil, err
+	}
+	Rx, Ry, _, err := ctx.nonce(nonces)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	index, RIx, RIy := ctx.find(privateKey, nonces)
+	if index < 0 {
+		return nil, nil, nil, ErrKeyNotInSet
+	}
+	return RIx, RIy, ctx.sign(message, privateKey, index, Rx, Ry), nil
+}
+
+// AppendSignature 在 signed 中的参与者的签名结果上追加自己的签名，signed 为空时 signInput 被忽略
+func (ctx *KeyAggContext) AppendSignature(signInput [64]byte, message []byte, privateKey *PrivateKey, nonces [][33]byte, signed []int) (signOutput [64]byte, err error) {
+	if err := checkPrivateKey(privateKey); err != nil {
+		return signOutput, err
+	}
+	if err := ctx.checkSigned(signed); err != nil {
+		return signOutput, err
+	}
+	Rx, Ry, points, err := ctx.nonce(nonces)
+	if err != nil {
+		return signOutput, err
+	}
+	index, RIx, RIy := ctx.find(privateKey, nonces)
+	if index < 0 {
+		return signOutput, ErrKeyNotInSet
+	}
+	if containsIndex(signed, index) {
+		return signOutput, ErrAlreadySigned
+	}
+
+	var R jacobianPoint
+	R.setBig(RIx, RIy)
+	s := ctx.sign(message, privateKey, index, Rx, Ry)
+	if len(signed) > 0 {
+		pubSignedP, pubSignedR, err := ctx.signedKey(signed, points)
+		if err != nil {
+			return signOutput, err
+		}
+		if _, err := ctx.verifyPartial(message, Rx, Ry, &pubSignedP, &pubSignedR, signInput); err != nil {
+			return signOutput, err
+		}
+		R.add(&R, &pubSignedR)
+		s.Add(s, new(big.Int).SetBytes(signInput[32:]))
+		s.Mod(s, Curve.N)
+	}
+	x, _ := R.affine()
+	xb := x.bytes()
+	copy(signOutput[:32], xb[:])
+	copy(signOutput[32:], IntToByte(s))
+	return signOutput, nil
+}
+
+// VerifySignInput 验证 signed 中的参与者签名的中间结果，signed 为空时返回 true
+func (ctx *KeyAggContext) VerifySignInput(signed []int, nonces [][33]byte, message []byte, signInput [64]byte) (bool, error) {
+	if err := ctx.checkSigned(signed); err != nil {
+		return false, err
+	}
+	if len(signed) == 0 {
+		return true, nil //没有签过
+	}
+	Rx, Ry, points, err := ctx.nonce(nonces)
+	if err != nil {
+		return false, err
+	}
+	pubSignedP, pubSignedR, err := ctx.signedKey(signed, points)
+	if err != nil {
+		return false, err
+	}
+	return ctx.verifyPartial(message, Rx, Ry, &pubSignedP, &pubSignedR, signInput)
+}
+
+// MultiVerify 验证聚合签名
+func (ctx *KeyAggContext) MultiVerify(message []byte, signature [64]byte) (bool, error) {
+	return Verify(ctx.publicKey, ctx.o.message(ctx.agg, message), signature)
+}
+
+// nonce 所有参与者的 R 之和，设置了 adaptor 时再加上 T
+// points 是解析后的每个 R，计算部分参与者的和时不需要再解析
+func (ctx *KeyAggContext) nonce(nonces [][33]byte) (Rx, Ry *big.Int, points []jacobianPoint, err error) {
+	if len(nonces) != len(ctx.keys) {
+		return nil, nil, nil, fmt.Errorf("%w: nonces size is not equal to publicKeys", ErrSizeMismatch)
+	}
+	points, ok := decompressPoints(nonces)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: invalid nonce", ErrInvalidPoint)
+	}
+	R := combine(points, nil)
+	if R.isInfinity() {
+		return nil, nil, nil, fmt.Errorf("%w: aggregate nonce is infinity", ErrInvalidPoint)
+	}
+	Rx, Ry = R.big()
+	Rx, Ry, err = ctx.o.nonce(Rx, Ry)
+	return Rx, Ry, points, err
+}
+
+// find privateKey 对应的 (P, R) 的序号，不存在时返回 -1
+// RIx, RIy 是 K0*G
+func (ctx *KeyAggContext) find(privateKey *PrivateKey, nonces [][33]byte) (index int, RIx, RIy *big.Int) {
+	PIx, PIy := ScalarBaseMult(privateKey.D)
+	RIx, RIy = ScalarBaseMult(privateKey.K0)
+	var P, R [33]byte
+	copy(P[:], Marshal(Curve, PIx, PIy))
+	copy(R[:], Marshal(Curve, RIx, RIy))
+	for _, i := range ctx.index[P] {
+		if nonces[i] == R {
+			return i, RIx, RIy
+		}
+	}
+	return -1, RIx, RIy
+}
+
+// sign s = k + e*a*d，Rx, Ry 是所有参与者的随机数之和
+// WithTweak 时 s = k ± e*a*d，设置了 WithTweak 或 WithPlainTweak 时第一个参与者再加上 e*tweak
+func (ctx *KeyAggContext) sign(message []byte, privateKey *PrivateKey, index int, Rx, Ry *big.Int) *big.Int {
+	e := getE(ctx.px, ctx.py, IntToByte(Rx), ctx.o.message(ctx.agg, message))
+	c := new(big.Int).Set(e)
+	if ctx.coefficients != nil {
+		c.Mul(c, ctx.coefficients[index])
+	}
+	if ctx.negate {
+		c.Neg(c)
+		c.Mod(c, Curve.N)
+	}
+	s := response(&privateKey.K0, &privateKey.D, big.Jacobi(Ry, Curve.P) != 1, c)
+	if ctx.tweak != nil && index == 0 {
+		s.Add(s, e.Mul(e, ctx.tweak))
+		s.Mod(s, Curve.N)
+	}
+	return s
+}
+
+// signedKey signed 中的参与者的聚合公钥 Σ ai*Pi 和随机数之和 Σ Ri，nonces 是 ctx.nonce 解析后的 R
+func (ctx *KeyAggContext) signedKey(signed []int, nonces []jacobianPoint) (P, R jacobianPoint, err error) {
+	points := make([]jacobianPoint, len(signed))
+	var scalars []*big.Int
+	for j, i := range signed {
+		points[j] = ctx.points[i]
+		if ctx.coefficients != nil {
+			scalars = append(scalars, ctx.coefficients[i])
+		}
+		R.add(&R, &nonces[i])
+	}
+	P = combine(points, scalars)
+	if ctx.negate {
+		P.neg(&P)
+	}
+	if ctx.tweak != nil && containsIndex(signed, 0) {
+		tG := straussMult(ctx.tweak, nil, nil)
+		P.add(&P, &tG)
+	}
+	if P.isInfinity() {
+		return P, R, fmt.Errorf("%w: aggregate public key is infinity", ErrInvalidPoint)
+	}
+	if R.isInfinity() {
+		return P, R, fmt.Errorf("%w: aggregate nonce is infinity", ErrInvalidPoint)
+	}
+	return P, R, nil
+}
+
+// checkSigned 序号不能越界或者重复
+func (ctx *KeyAggContext) checkSigned(signed []int) error {
+	seen := make(map[int]bool)
+	for _, i := range signed {
+		if i < 0 || i >= len(ctx.keys) {
+			return ErrInvalidIndex
+		}
+		if seen[i] {
+			return ErrDuplicateIndex
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+// verifyPartial 验证 s*G - e*P' = R'，P' 和 R' 是已经签名的参与者的聚合公钥和随机数之和
+// Rx, Ry 是所有参与者的随机数之和，用于计算 e
+func (ctx *KeyAggContext) verifyPartial(message []byte, Rx, Ry *big.Int, pubSignedP, pubSignedR *jacobianPoint, signInput [64]byte) (bool, error) {
+	r, s, err := parseSignature(signInput)
+	if err != nil {
+		return false, err
+	}
+	e := getE(ctx.px, ctx.py, IntToByte(Rx), ctx.o.message(ctx.agg, message))
+	x1, y1, ok := verifyPoint(s, e, pubSignedP)
+	if !ok {
+		return false, fmt.Errorf("%w : Rx1, Rx1 are zero", ErrVerificationFailed)
+	}
+	Rx1, Ry1 := x1.big(), y1.big()
+	pubSignedRx, pubSignedRy := pubSignedR.big()
+	if pubSignedRx.Cmp(r) != 0 {
+		return false, fmt.Errorf("%w : pubSignedRx is not equal r", ErrVerificationFailed)
+	}
+	if Rx1.Cmp(r) != 0 {
+		return false, fmt.Errorf("%w : Rx1 is not equal r", ErrVerificationFailed)
+	}
+	// 所有的k都根据Ry是否jacobi做过调整, 因此通过s计算出的Ry1也是做过调整的。
+	// big.Jacobi(Ry, Curve.P) != 1 成立是，Ry1 和 pubSignedRy 是反的。
+	if big.Jacobi(Ry, Curve.P) != 1 {
+		Ry1 = new(big.Int).Sub(Curve.P, Ry1)
+	}
+	if Ry1.Cmp(pubSignedRy) != 0 {
+		return false, fmt.Errorf("%w : Ry1 is not equal pubSignedRy", ErrVerificationFailed)
+	}
+	return true, nil
+}
+
+func containsIndex(indices []int, index int) bool {
+	for _, i := range indices {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
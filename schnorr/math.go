@@ -1,9 +1,7 @@
 package schnorr
 
 import (
-	"crypto/hmac"
 	"crypto/sha256"
-	"crypto/sha512"
 	"math/big"
 )
 
@@ -14,104 +12,142 @@ func IntToByte(i *big.Int) []byte {
 }
 
 func getE(Px, Py *big.Int, rX []byte, m []byte) *big.Int {
-	r := append(rX, Marshal(Curve, Px, Py)...)
+	return challenge(rX, Marshal(Curve, Px, Py), m)
+}
+
+// challenge e = sha256(Rx||P||m) mod N，P 是33字节压缩公钥
+func challenge(rX []byte, P []byte, m []byte) *big.Int {
+	r := append(append([]byte{}, rX...), P...)
 	r = append(r, m[:]...)
 	h := sha256.Sum256(r)
 	i := new(big.Int).SetBytes(h[:])
 	return i.Mod(i, Curve.N)
 }
 
-func getK(Ry, k0 *big.Int) *big.Int {
-	if big.Jacobi(Ry, Curve.P) == 1 {
-		return k0
-	}
-	return k0.Sub(Curve.N, k0)
+// Challenge 签名使用的 e = sha256(Rx||P||m) mod N
+// 门限签名等其他协议用它生成可以被 Verify 验证的签名
+func Challenge(Rx [32]byte, P [33]byte, message []byte) *big.Int {
+	return challenge(Rx[:], P[:], message)
 }
 
-func aggregationPublicKey(publicKeys []*PublicKey) *PublicKey {
-	if(len(publicKeys) == 0) {
-		return nil
-	}
+const (
+	keyAggListTag = "schnorr-go/keyagg-list"
+	keyAggCoefTag = "schnorr-go/keyagg-coef"
+)
 
-	resultRx, resultRy := Unmarshal(Curve, publicKeys[0].R[:])
-	resultPx, resultPy := Unmarshal(Curve, publicKeys[0].P[:])
-	for i := 1; i < len(publicKeys); i++ {
-		childRx, childRy := Unmarshal(Curve, publicKeys[i].R[:])
-		Px, Py := Unmarshal(Curve, publicKeys[i].P[:])
-		resultRx, resultRy = Curve.Add(resultRx, resultRy, childRx, childRy)
-		resultPx, resultPy = Curve.Add(resultPx, resultPy, Px, Py)
-	}
-	resultR := Marshal(Curve, resultRx, resultRy)
-	resultP := Marshal(Curve, resultPx, resultPy)
-	var result PublicKey
-	copy(result.R[:], resultR)
-	copy(result.P[:], resultP)
-	return &result
+// keyAggregator 计算每个公钥的聚合系数
+type keyAggregator struct {
+	mode     AggregationMode
+	keysHash [32]byte
 }
 
-func aggregationPubKey(publicKeys [][33]byte) (pubkey [33]byte) {
-	if(len(publicKeys) == 0) {
-		return pubkey
+// newKeyAggregator publicKeys 是所有参与签名的公钥
+func newKeyAggregator(publicKeys [][33]byte, o *options) *keyAggregator {
+	agg := &keyAggregator{mode: o.mode}
+	if o.subgroup != nil {
+		// 系数由登记的全部公钥计算
+		agg.mode = AggregationHardened
+		publicKeys = o.subgroup.keySet
 	}
-
-	resultX, resultY := Zero, Zero
-	for i := 0; i < len(publicKeys); i++ {
-		x, y := Unmarshal(Curve, publicKeys[i][:])
-		resultX, resultY = Curve.Add(resultX, resultY, x, y)
+	if agg.mode == AggregationHardened {
+		var buf []byte
+		for _, publicKey := range publicKeys {
+			buf = append(buf, publicKey[:]...)
+		}
+		agg.keysHash = TaggedHash(keyAggListTag, buf)
 	}
-	result := Marshal(Curve, resultX, resultY)
-	copy(pubkey[:], result)
-
-	return pubkey
+	return agg
 }
 
-//用P计算Rx
-func GetPublicRx(P [33]byte, message []byte) [32]byte {
-	Px, Py := Unmarshal(Curve, P[:])
-	ilNum := computChildOffset(IntToByte(Px), IntToByte(Py), message)
-
-	ilx, ily := Curve.ScalarBaseMult(IntToByte(ilNum))
-	Rx, _ := Curve.Add(ilx, ily, Px, Py)
+// coefficient 公钥P的聚合系数，AggregationSum 时为1
+func (agg *keyAggregator) coefficient(P [33]byte) *big.Int {
+	if agg.mode != AggregationHardened {
+		return new(big.Int).Set(One)
+	}
+	h := TaggedHash(keyAggCoefTag, agg.keysHash[:], P[:])
+	a := new(big.Int).SetBytes(h[:])
+	return a.Mod(a, Curve.N)
+}
 
-	var ret [32]byte
-	copy(ret[:], IntToByte(Rx))
+func publicKeysP(publicKeys []*PublicKey) [][33]byte {
+	var ret [][33]byte
+	for _, publicKey := range publicKeys {
+		ret = append(ret, publicKey.P)
+	}
 	return ret
 }
 
-//用P计算R
-func GetPublicR(P [33]byte, message []byte) [33]byte {
-	Px, Py := Unmarshal(Curve, P[:])
-	ilNum := computChildOffset(IntToByte(Px), IntToByte(Py), message)
-
-	ilx, ily := Curve.ScalarBaseMult(IntToByte(ilNum))
-	Rx, Ry := Curve.Add(ilx, ily, Px, Py)
+// aggregateKeysMSM 需要系数的公钥数量达到这个值时使用 Pippenger，否则使用 Strauss
+const aggregateKeysMSM = 16
 
-	R := Marshal(Curve, Rx, Ry)
-	var ret [33]byte
-	copy(ret[:], R)
-	return ret
+// coefficients 每个公钥的聚合系数，AggregationSum 时为 nil
+func (agg *keyAggregator) coefficients(publicKeys [][33]byte) []*big.Int {
+	if agg.mode != AggregationHardened {
+		return nil
+	}
+	scalars := make([]*big.Int, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		scalars[i] = agg.coefficient(publicKey)
+	}
+	return scalars
 }
 
-//用d计算k0
-func GetPrivateK0(d [32]byte, message []byte) [32]byte {
-	Px, Py := Curve.ScalarBaseMult(d[:])
-	ilNum := computChildOffset(IntToByte(Px), IntToByte(Py), message)
+// combine 计算 Σ ai*Pi，scalars 为 nil 时直接相加
+func combine(points []jacobianPoint, scalars []*big.Int) jacobianPoint {
+	var result jacobianPoint
+	switch {
+	case scalars == nil:
+		for i := range points {
+			result.add(&result, &points[i])
+		}
+	case len(points) >= aggregateKeysMSM:
+		result = multiScalarMult(points, scalars)
+	default:
+		result = straussMult(Zero, points, scalars)
+	}
+	return result
+}
 
-	k0Num := new(big.Int).SetBytes(d[:])
-	k0Num = k0Num.Add(k0Num, ilNum)
-	k0Num = k0Num.Mod(k0Num, Curve.N)
+// decompressPoints 解析一组压缩点，有一个不合法时 ok 为 false
+func decompressPoints(points [][33]byte) ([]jacobianPoint, bool) {
+	ret := make([]jacobianPoint, len(points))
+	for i, point := range points {
+		P, ok := decompress(point[:])
+		if !ok {
+			return nil, false
+		}
+		ret[i] = P
+	}
+	return ret, true
+}
 
-	var k0 [32]byte
-	copy(k0[:], IntToByte(k0Num))
-	return k0
+// compress 33字节压缩格式，p 不能是无穷远点
+func compress(p *jacobianPoint) (ret [33]byte) {
+	x, y := p.affine()
+	xb := x.bytes()
+	ret[0] = 2
+	if y.isOdd() {
+		ret[0] = 3
+	}
+	copy(ret[1:], xb[:])
+	return ret
 }
 
-func computChildOffset(X, Y, message []byte) *big.Int  {
-	hmac512 := hmac.New(sha512.New, X)
-	hmac512.Write(Y)
-	hmac512.Write(message)
-	i := hmac512.Sum(nil)
-	ilNum := new(big.Int).SetBytes(i[:32])
+// isInfinity btcec 用 (0, 0) 表示无穷远点
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
 
-	return ilNum
-}
\ No newline at end of file
+// TaggedHash 带域分隔的哈希 sha256(sha256(tag)||sha256(tag)||msg...)，与 BIP-340 的定义一致
+func TaggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	var ret [32]byte
+	copy(ret[:], h.Sum(nil))
+	return ret
+}
@@ -6,24 +6,40 @@ import (
 	"testing"
 )
 
-func TestGetK0(t *testing.T)  {
+func TestGenNonce(t *testing.T)  {
 	message, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
 	d, _ := hex.DecodeString("b2b084220e17de5bb85c6b33fe4630dc0cc3a0382c49509461a26341bc3c27e4")
-	P, _ := hex.DecodeString("021b34e02fbfab6153513c7578de070e1c9f2654b88109fb3906bb7f63dffd957d")
+	aux, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
 
 	var d32 [32]byte
-	var P33 [33]byte
-
 	copy(d32[:], d)
-	copy(P33[:], P)
 
-	k0 := GetPrivateK0(d32, message)
-	Rx := GetPublicRx(P33, message)
+	k0, R, err := GenNonce(d32, message, aux)
+	if err != nil {
+		panic(err)
+	}
+	Rx, Ry := Curve.ScalarBaseMult(k0[:])
+	if !bytes.Equal(R[:], Marshal(Curve, Rx, Ry)) {
+		panic("R 不相等")
+	}
 
-	Rx1, _ := Curve.ScalarBaseMult(k0[:])
+	k1, _, err := GenNonce(d32, message, aux)
+	if err != nil {
+		panic(err)
+	}
+	if k0 != k1 {
+		panic("相同的输入应该得到相同的k0")
+	}
 
-	if !bytes.Equal(Rx[:], IntToByte(Rx1)) {
-		panic("R 不相等")
+	k2, _, err := GenNonce(d32, message, []byte("another aux"))
+	if err != nil {
+		panic(err)
+	}
+	if k0 == k2 {
+		panic("不同的aux应该得到不同的k0")
 	}
-}
 
+	if _, _, err := GenNonce([32]byte{}, message, aux); err == nil {
+		panic("私钥为0应该失败")
+	}
+}
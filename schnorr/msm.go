@@ -0,0 +1,203 @@
+package schnorr
+
+import (
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// scalarLimbs 把 [0, N) 之间的标量转成4个64位的limb，小端序
+func scalarLimbs(k *big.Int) (l [4]uint64) {
+	b := IntToByte(k)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			l[i] |= uint64(b[31-8*i-j]) << (8 * j)
+		}
+	}
+	return l
+}
+
+// window 取出 l 的第 bit 位开始的 c 位
+func window(l *[4]uint64, bit, c int) int {
+	limb, shift := bit/64, uint(bit%64)
+	w := l[limb] >> shift
+	if shift+uint(c) > 64 && limb < 3 {
+		w |= l[limb+1] << (64 - shift)
+	}
+	return int(w & (1<<uint(c) - 1))
+}
+
+// pippengerWindow 根据点的数量选择窗口大小
+func pippengerWindow(n int) int {
+	switch {
+	case n < 8:
+		return 3
+	case n < 32:
+		return 4
+	default:
+		// 大约 log2(n) - 2
+		c := bits.Len(uint(n)) - 2
+		if c > 16 {
+			c = 16
+		}
+		return c
+	}
+}
+
+// multiScalarMult Pippenger 算法计算 Σ ki*Pi，变时间实现，只能用于公开数据(验签)
+// 每个窗口把点按照该窗口的值放进桶里，再用累加的方式计算 Σ j*bucket[j]
+func multiScalarMult(points []jacobianPoint, scalars []*big.Int) jacobianPoint {
+	c := pippengerWindow(len(points))
+	limbs := make([][4]uint64, len(scalars))
+	for i, k := range scalars {
+		limbs[i] = scalarLimbs(k)
+	}
+	buckets := make([]jacobianPoint, 1<<uint(c))
+
+	var result jacobianPoint
+	top := (256 + c - 1) / c * c
+	for bit := top - c; bit >= 0; bit -= c {
+		for i := 0; i < c; i++ {
+			result.double(&result)
+		}
+		for i := range buckets {
+			buckets[i] = jacobianPoint{}
+		}
+		for i := range points {
+			if w := window(&limbs[i], bit, c); w != 0 {
+				buckets[w].add(&buckets[w], &points[i])
+			}
+		}
+		var running, sum jacobianPoint
+		for j := len(buckets) - 1; j > 0; j-- {
+			running.add(&running, &buckets[j])
+			sum.add(&sum, &running)
+		}
+		result.add(&result, &sum)
+	}
+	return result
+}
+
+const (
+	// wnafWindowG G 的奇数倍数预先计算，窗口可以大一些
+	wnafWindowG = 8
+	// wnafWindowP 其他点的奇数倍数每次临时计算
+	wnafWindowP = 5
+)
+
+// wnafTableG[i] = (2i+1)*G，仿射坐标(Z = 1)，加法走混合加法的快速路径
+var (
+	wnafTableG     [1 << (wnafWindowG - 2)]jacobianPoint
+	wnafTableGOnce sync.Once
+)
+
+func initWnafTableG() {
+	G := basePoint()
+	var G2 jacobianPoint
+	G2.double(&G)
+	P := G
+	for i := range wnafTableG {
+		x, y := P.affine()
+		wnafTableG[i].setAffine(&x, &y)
+		P.add(&P, &G2)
+	}
+}
+
+// wnaf k 的宽度为 w 的 NAF 表示，naf[i] 为0或者 (-2^(w-1), 2^(w-1)) 之间的奇数
+// k = Σ naf[i]*2^i，非零的位之间至少隔 w-1 个0
+func wnaf(k *big.Int, w uint) (naf [257]int8, n int) {
+	l := scalarLimbs(k)
+	x := [5]uint64{l[0], l[1], l[2], l[3]}
+	for i := 0; x[0]|x[1]|x[2]|x[3]|x[4] != 0; i++ {
+		if x[0]&1 == 1 {
+			m := int64(x[0] & (1<<w - 1))
+			if m >= 1<<(w-1) {
+				m -= 1 << w
+			}
+			naf[i] = int8(m)
+			// x = x - m
+			var c uint64
+			if m > 0 {
+				x[0], c = bits.Sub64(x[0], uint64(m), 0)
+				for j := 1; j < 5; j++ {
+					x[j], c = bits.Sub64(x[j], 0, c)
+				}
+			} else {
+				x[0], c = bits.Add64(x[0], uint64(-m), 0)
+				for j := 1; j < 5; j++ {
+					x[j], c = bits.Add64(x[j], 0, c)
+				}
+			}
+		}
+		// x = x / 2
+		for j := 0; j < 4; j++ {
+			x[j] = x[j]>>1 | x[j+1]<<63
+		}
+		x[4] >>= 1
+		n = i + 1
+	}
+	return naf, n
+}
+
+// addNaf r = r + d*table，table[i] = (2i+1)*P
+func addNaf(r *jacobianPoint, table []jacobianPoint, d int8) {
+	switch {
+	case d > 0:
+		r.add(r, &table[d/2])
+	case d < 0:
+		var t jacobianPoint
+		t.neg(&table[-d/2])
+		r.add(r, &t)
+	}
+}
+
+// doubleScalarMult Strauss-Shamir 算法计算 a*G + b*P，变时间实现，只能用于公开数据(验签)
+func doubleScalarMult(a *big.Int, b *big.Int, P *jacobianPoint) jacobianPoint {
+	return straussMult(a, []jacobianPoint{*P}, []*big.Int{b})
+}
+
+// straussMult 计算 a*G + Σ ki*Pi，变时间实现，只能用于公开数据
+// 所有标量转成 wNAF，共用同一串倍点，G 的奇数倍数是预先计算的，Pi 的奇数倍数临时计算
+// 点的数量较少时比 Pippenger 快
+func straussMult(a *big.Int, points []jacobianPoint, scalars []*big.Int) jacobianPoint {
+	wnafTableGOnce.Do(initWnafTableG)
+	nafA, n := wnaf(a, wnafWindowG)
+
+	nafs := make([][257]int8, len(points))
+	tables := make([][1 << (wnafWindowP - 2)]jacobianPoint, len(points))
+	for j := range points {
+		var nj int
+		nafs[j], nj = wnaf(scalars[j], wnafWindowP)
+		if nj > n {
+			n = nj
+		}
+		var P2 jacobianPoint
+		P2.double(&points[j])
+		tables[j][0] = points[j]
+		for i := 1; i < len(tables[j]); i++ {
+			tables[j][i].add(&tables[j][i-1], &P2)
+		}
+	}
+
+	var r jacobianPoint
+	for i := n - 1; i >= 0; i-- {
+		r.double(&r)
+		addNaf(&r, wnafTableG[:], nafA[i])
+		for j := range points {
+			addNaf(&r, tables[j][:], nafs[j][i])
+		}
+	}
+	return r
+}
+
+// verifyPoint 计算验签用的 R = s*G - e*P，结果为无穷远点时 ok 为 false
+func verifyPoint(s, e *big.Int, P *jacobianPoint) (x, y fieldElement, ok bool) {
+	negE := new(big.Int).Sub(Curve.N, e)
+	negE.Mod(negE, Curve.N)
+	R := doubleScalarMult(s, negE, P)
+	if R.isInfinity() {
+		return x, y, false
+	}
+	x, y = R.affine()
+	return x, y, true
+}
@@ -0,0 +1,239 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randPoint(t *testing.T) (jacobianPoint, *big.Int, *big.Int) {
+	k, err := rand.Int(rand.Reader, Curve.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := Curve.ScalarBaseMult(IntToByte(k))
+	var p jacobianPoint
+	p.setBig(x, y)
+	return p, x, y
+}
+
+func checkPoint(t *testing.T, p *jacobianPoint, x, y *big.Int) {
+	px, py := p.big()
+	if px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+		t.Fatalf("point mismatch: (%x, %x) != (%x, %x)", px, py, x, y)
+	}
+}
+
+func TestGroupArithmetic(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		a, ax, ay := randPoint(t)
+		b, bx, by := randPoint(t)
+		var r jacobianPoint
+		x, y := Curve.Add(ax, ay, bx, by)
+		checkPoint(t, r.add(&a, &b), x, y)
+		// 两个 Jacobian 坐标的点相加
+		var a2, b2 jacobianPoint
+		a2.double(&a)
+		b2.double(&b)
+		x, y = Curve.Add(x, y, x, y)
+		checkPoint(t, r.add(&a2, &b2), x, y)
+
+		x, y = Curve.Double(ax, ay)
+		checkPoint(t, r.add(&a, &a), x, y)
+		checkPoint(t, r.double(&a), x, y)
+
+		var neg jacobianPoint
+		neg.neg(&a)
+		if !r.add(&a, &neg).isInfinity() {
+			t.Fatal("a + (-a) should be infinity")
+		}
+		var inf jacobianPoint
+		checkPoint(t, r.add(&inf, &a), ax, ay)
+		checkPoint(t, r.add(&a, &inf), ax, ay)
+
+		var compressed [33]byte
+		copy(compressed[:], Marshal(Curve, ax, ay))
+		d, ok := decompress(compressed[:])
+		if !ok {
+			t.Fatal("decompress failed")
+		}
+		checkPoint(t, &d, ax, ay)
+	}
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 50} {
+		var points []jacobianPoint
+		var scalars []*big.Int
+		x, y := Zero, Zero
+		for i := 0; i < n; i++ {
+			p, px, py := randPoint(t)
+			k, _ := rand.Int(rand.Reader, Curve.N)
+			if i == 0 {
+				k = new(big.Int).Sub(Curve.N, One)
+			}
+			points = append(points, p)
+			scalars = append(scalars, k)
+			kx, ky := Curve.ScalarMult(px, py, IntToByte(k))
+			x, y = Curve.Add(x, y, kx, ky)
+		}
+		result := multiScalarMult(points, scalars)
+		checkPoint(t, &result, x, y)
+	}
+}
+
+func TestWnaf(t *testing.T) {
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(255), new(big.Int).Sub(Curve.N, One)}
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, Curve.N)
+		values = append(values, k)
+	}
+	for _, k := range values {
+		for _, w := range []uint{wnafWindowP, wnafWindowG} {
+			naf, n := wnaf(k, w)
+			sum := new(big.Int)
+			for i := n - 1; i >= 0; i-- {
+				sum.Lsh(sum, 1)
+				sum.Add(sum, big.NewInt(int64(naf[i])))
+				d := int(naf[i])
+				if d != 0 && (d%2 == 0 || d >= 1<<(w-1) || d <= -(1<<(w-1))) {
+					t.Fatalf("invalid digit %d", naf[i])
+				}
+			}
+			if sum.Cmp(k) != 0 {
+				t.Fatalf("wnaf(%x, %d) = %x", k, w, sum)
+			}
+		}
+	}
+}
+
+func TestDoubleScalarMult(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p, px, py := randPoint(t)
+		a, _ := rand.Int(rand.Reader, Curve.N)
+		b, _ := rand.Int(rand.Reader, Curve.N)
+		switch i {
+		case 0:
+			a = big.NewInt(0)
+		case 1:
+			b = big.NewInt(0)
+		case 2:
+			a = new(big.Int).Sub(Curve.N, One)
+		}
+		aGx, aGy := Curve.ScalarBaseMult(IntToByte(a))
+		bPx, bPy := Curve.ScalarMult(px, py, IntToByte(b))
+		x, y := Curve.Add(aGx, aGy, bPx, bPy)
+		r := doubleScalarMult(a, b, &p)
+		checkPoint(t, &r, x, y)
+	}
+	// a*G - a*G = O
+	a, _ := rand.Int(rand.Reader, Curve.N)
+	G := basePoint()
+	if r := doubleScalarMult(a, new(big.Int).Sub(Curve.N, a), &G); !r.isInfinity() {
+		t.Fatal("a*G - a*G should be infinity")
+	}
+}
+
+// referenceAggregate 逐个调用 Curve.ScalarMult 聚合公钥，用来对照
+func referenceAggregate(publicKeys [][33]byte, agg *keyAggregator) [33]byte {
+	x, y := Zero, Zero
+	for _, publicKey := range publicKeys {
+		Px, Py := Unmarshal(Curve, publicKey[:])
+		aPx, aPy := Curve.ScalarMult(Px, Py, IntToByte(agg.coefficient(publicKey)))
+		x, y = Curve.Add(x, y, aPx, aPy)
+	}
+	var ret [33]byte
+	copy(ret[:], Marshal(Curve, x, y))
+	return ret
+}
+
+func TestAggregateKeys(t *testing.T) {
+	for _, n := range []int{1, 2, aggregateKeysMSM - 1, aggregateKeysMSM, 40} {
+		keys := make([][33]byte, n)
+		for i := range keys {
+			_, keys[i] = GenKey()
+		}
+		for _, mode := range []AggregationMode{AggregationSum, AggregationHardened} {
+			ctx, err := NewKeyAggContext(keys, WithAggregation(mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ctx.PublicKey() != referenceAggregate(keys, ctx.agg) {
+				t.Fatalf("aggregate of %d keys mismatch", n)
+			}
+		}
+	}
+}
+
+// referenceMultiVerify 改用 Jacobian 坐标之前的 MultiVerify，用来对比性能
+func referenceMultiVerify(publicKeys [][33]byte, message []byte, signature [64]byte, agg *keyAggregator) bool {
+	P := referenceAggregate(publicKeys, agg)
+	Px, Py := Unmarshal(Curve, P[:])
+	r := new(big.Int).SetBytes(signature[:32])
+	e := getE(Px, Py, signature[:32], message)
+	sGx, sGy := Curve.ScalarBaseMult(signature[32:])
+	ePx, ePy := Curve.ScalarMult(Px, Py, IntToByte(e))
+	ePy.Sub(Curve.P, ePy)
+	Rx, Ry := Curve.Add(sGx, sGy, ePx, ePy)
+	return !isInfinity(Rx, Ry) && big.Jacobi(Ry, Curve.P) == 1 && Rx.Cmp(r) == 0
+}
+
+// genMultiSignature n 个公钥(AggregationHardened)的聚合签名，由聚合私钥 Σ ai*di 直接签出
+func genMultiSignature(b *testing.B, n int, message []byte) ([][33]byte, [64]byte) {
+	keys := make([][33]byte, n)
+	privateKeys := make([][32]byte, n)
+	for i := range keys {
+		privateKeys[i], keys[i] = GenKey()
+	}
+	agg := newKeyAggregator(keys, newOptions([]Option{WithAggregation(AggregationHardened)}))
+	d := new(big.Int)
+	for i, key := range keys {
+		ad := new(big.Int).Mul(agg.coefficient(key), new(big.Int).SetBytes(privateKeys[i][:]))
+		d.Add(d, ad)
+	}
+	var D [32]byte
+	copy(D[:], IntToByte(d.Mod(d, Curve.N)))
+	Dx, Dy := ScalarBaseMult(D)
+	var P [33]byte
+	copy(P[:], Marshal(Curve, Dx, Dy))
+	k0, R, err := GenNonce(D, message, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	Rx, _, s, err := Sign(message, &PrivateKey{D: D, K0: k0}, []*PublicKey{{P: P, R: R}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var signature [64]byte
+	copy(signature[:32], IntToByte(Rx))
+	copy(signature[32:], IntToByte(s))
+	return keys, signature
+}
+
+func benchmarkMultiVerify(b *testing.B, n int, reference bool) {
+	message := []byte("benchmark")
+	keys, signature := genMultiSignature(b, n, message)
+	opt := WithAggregation(AggregationHardened)
+	agg := newKeyAggregator(keys, newOptions([]Option{opt}))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var ret bool
+		if reference {
+			ret = referenceMultiVerify(keys, message, signature, agg)
+		} else {
+			ret, _ = MultiVerify(keys, message, signature, opt)
+		}
+		if !ret {
+			b.Fatal("verification failed")
+		}
+	}
+}
+
+func BenchmarkMultiVerify1(b *testing.B)              { benchmarkMultiVerify(b, 1, false) }
+func BenchmarkMultiVerify10(b *testing.B)             { benchmarkMultiVerify(b, 10, false) }
+func BenchmarkMultiVerify100(b *testing.B)            { benchmarkMultiVerify(b, 100, false) }
+func BenchmarkMultiVerify10000(b *testing.B)          { benchmarkMultiVerify(b, 10000, false) }
+func BenchmarkMultiVerifyReference1(b *testing.B)     { benchmarkMultiVerify(b, 1, true) }
+func BenchmarkMultiVerifyReference10(b *testing.B)    { benchmarkMultiVerify(b, 10, true) }
+func BenchmarkMultiVerifyReference100(b *testing.B)   { benchmarkMultiVerify(b, 100, true) }
+func BenchmarkMultiVerifyReference10000(b *testing.B) { benchmarkMultiVerify(b, 10000, true) }
@@ -0,0 +1,40 @@
+package schnorr
+
+import (
+	"fmt"
+)
+
+const (
+	nonceAuxTag = "schnorr-go/aux"
+	nonceTag    = "schnorr-go/nonce"
+)
+
+// GenNonce 由私钥、消息和辅助随机数计算签名随机数 k0 以及 R = k0*G
+// 做法同 BIP-340:
+// t = d xor TaggedHash("schnorr-go/aux", aux)
+// k0 = TaggedHash("schnorr-go/nonce", t||P||msg) mod N
+// k0 只有持有私钥的人才能算出，R 需要发给其他参与者。
+// aux 每次签名都应该重新随机生成，同一个 k0 不能用于两次签名，
+// 否则对方换一组 R 就能解出私钥。
+func GenNonce(d [32]byte, message []byte, aux []byte) (k0 [32]byte, R [33]byte, err error) {
+	if err := checkScalar(d); err != nil {
+		return k0, R, fmt.Errorf("%w: invalid private key", err)
+	}
+	Px, Py := ScalarBaseMult(d)
+
+	t := TaggedHash(nonceAuxTag, aux)
+	for i := range t {
+		t[i] ^= d[i]
+	}
+	h := TaggedHash(nonceTag, t[:], Marshal(Curve, Px, Py), message)
+	var k scalar
+	k.setBytes(&h)
+	if k.isZero() == 1 {
+		return k0, R, fmt.Errorf("%w: invalid nonce", ErrScalarOutOfRange)
+	}
+	k0 = k.bytes()
+
+	Rx, Ry := baseMult(&k)
+	copy(R[:], Marshal(Curve, Rx, Ry))
+	return k0, R, nil
+}
@@ -0,0 +1,89 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// AggregationMode 公钥聚合方式
+type AggregationMode int
+
+const (
+	// AggregationSum 直接相加 P = P1 + P2 + ... + Pm
+	// 需要事先确认每个公钥确实属于其本人，否则恶意参与者可以选 P' = X - (P1 + ... + Pm-1)，
+	// 一个人就能控制聚合公钥(rogue key 攻击)
+	AggregationSum AggregationMode = iota
+	// AggregationHardened 带系数相加 P = a1*P1 + a2*P2 + ... + am*Pm
+	// L = TaggedHash("schnorr-go/keyagg-list", P1||P2||...||Pm)
+	// ai = TaggedHash("schnorr-go/keyagg-coef", L||Pi) mod N
+	// 系数依赖整个公钥集合，恶意参与者无法事先凑出想要的聚合公钥
+	AggregationHardened
+)
+
+var errInvalidAdaptor = fmt.Errorf("%w: invalid adaptor point", ErrInvalidPoint)
+
+// Option 签名和验签的可选配置，所有参与者和验证者必须使用相同的配置
+type Option func(*options)
+
+type options struct {
+	mode       AggregationMode
+	adaptor    *[33]byte
+	subgroup   *subgroup
+	tweak      *tweak
+	plainTweak *[32]byte
+}
+
+// WithAggregation 设置公钥聚合方式，默认为 AggregationSum
+func WithAggregation(mode AggregationMode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithAdaptor 生成适配器签名(预签名)，最终的随机数为 R + T
+// 所有参与者得到的结果用 PreVerify 验证，知道 t 的人可以用 Adapt 补全成普通签名
+func WithAdaptor(T [33]byte) Option {
+	return func(o *options) {
+		o.adaptor = &T
+	}
+}
+
+// nonce 签名实际使用的随机数点，设置了 adaptor 时为 R + T
+func (o *options) nonce(Rx, Ry *big.Int) (x, y *big.Int, err error) {
+	if o.adaptor == nil {
+		return Rx, Ry, nil
+	}
+	Tx, Ty := Unmarshal(Curve, o.adaptor[:])
+	if Tx == nil {
+		return nil, nil, errInvalidAdaptor
+	}
+	x, y = Curve.Add(Rx, Ry, Tx, Ty)
+	if isInfinity(x, y) {
+		return nil, nil, errInvalidAdaptor
+	}
+	return x, y, nil
+}
+
+// check 检查参与签名的公钥是否符合配置
+func (o *options) check(publicKeys [][33]byte) error {
+	if o.subgroup == nil {
+		return nil
+	}
+	return o.subgroup.checkKeys(publicKeys)
+}
+
+// message 实际签名的消息，设置了 subgroup 时绑定 keySet 和 bitmap
+func (o *options) message(agg *keyAggregator, message []byte) []byte {
+	if o.subgroup == nil {
+		return message
+	}
+	return o.subgroup.message(agg.keysHash, message)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{mode: AggregationSum}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
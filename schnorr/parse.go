@@ -0,0 +1,91 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ParsePublicKey 解析 P||R 共66字节的公钥，P 和 R 都必须是合法的压缩点
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	if len(data) != 66 {
+		return nil, fmt.Errorf("%w: public key must be 66 bytes", ErrInvalidLength)
+	}
+	publicKey := &PublicKey{}
+	copy(publicKey.P[:], data[:33])
+	copy(publicKey.R[:], data[33:])
+	if err := checkPublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// ParseSignature 解析 r||s 共64字节的签名，要求 r < p，s < N
+func ParseSignature(data []byte) (signature [64]byte, err error) {
+	if len(data) != 64 {
+		return signature, fmt.Errorf("%w: signature must be 64 bytes", ErrInvalidLength)
+	}
+	copy(signature[:], data)
+	if _, _, err := parseSignature(signature); err != nil {
+		return [64]byte{}, err
+	}
+	return signature, nil
+}
+
+// parseSignature 取出签名中的 r 和 s
+func parseSignature(signature [64]byte) (r, s *big.Int, err error) {
+	r = new(big.Int).SetBytes(signature[:32])
+	if r.Cmp(Curve.P) >= 0 {
+		return nil, nil, fmt.Errorf("%w: r is larger than or equal to field size", ErrInvalidPoint)
+	}
+	s = new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(Curve.N) >= 0 {
+		return nil, nil, fmt.Errorf("%w: s is larger than or equal to curve order", ErrScalarOutOfRange)
+	}
+	return r, s, nil
+}
+
+// parsePublicKey 解析压缩公钥
+func parsePublicKey(publicKey [33]byte) (x, y *big.Int, err error) {
+	x, y = Unmarshal(Curve, publicKey[:])
+	if x == nil {
+		return nil, nil, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	return x, y, nil
+}
+
+// checkPublicKey 检查 P 和 R 都是合法的点
+func checkPublicKey(publicKey *PublicKey) error {
+	if publicKey == nil {
+		return fmt.Errorf("%w: nil public key", ErrInvalidPoint)
+	}
+	if _, _, err := parsePublicKey(publicKey.P); err != nil {
+		return err
+	}
+	if x, _ := Unmarshal(Curve, publicKey.R[:]); x == nil {
+		return fmt.Errorf("%w: invalid nonce", ErrInvalidPoint)
+	}
+	return nil
+}
+
+// checkScalar 检查 1 <= k < N，k 可能是私钥，比较不依赖数值分支
+func checkScalar(k [32]byte) error {
+	var s scalar
+	if s.setBytes(&k)|s.isZero() != 0 {
+		return ErrScalarOutOfRange
+	}
+	return nil
+}
+
+// checkPrivateKey 检查私钥和随机数都在 [1, N) 内
+func checkPrivateKey(privateKey *PrivateKey) error {
+	if privateKey == nil {
+		return fmt.Errorf("%w: nil private key", ErrScalarOutOfRange)
+	}
+	if err := checkScalar(privateKey.D); err != nil {
+		return fmt.Errorf("%w: invalid private key", err)
+	}
+	if err := checkScalar(privateKey.K0); err != nil {
+		return fmt.Errorf("%w: invalid nonce", err)
+	}
+	return nil
+}
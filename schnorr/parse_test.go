@@ -0,0 +1,178 @@
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestUnmarshalInvalid(t *testing.T) {
+	// x >= p 的编码必须拒绝，即使 x mod p 是合法的 x 坐标
+	var xp [33]byte
+	xp[0] = 2
+	copy(xp[1:], IntToByte(Curve.P))
+	var xp1 [33]byte
+	xp1[0] = 2
+	copy(xp1[1:], IntToByte(new(big.Int).Add(Curve.P, One)))
+	for _, data := range [][]byte{nil, {}, {2}, {4}, xp[:], xp1[:], make([]byte, 33), make([]byte, 65)} {
+		if x, y := Unmarshal(Curve, data); x != nil || y != nil {
+			t.Fatalf("Unmarshal(%x) should fail", data)
+		}
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	d, P := GenKey()
+	_, R, err := GenNonce(d, []byte("msg"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(append([]byte{}, P[:]...), R[:]...)
+	publicKey, err := ParsePublicKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicKey.P != P || publicKey.R != R {
+		t.Fatal("public key mismatch")
+	}
+	if _, err := ParsePublicKey(data[:65]); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := ParsePublicKey(nil); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	bad := append([]byte{}, data...)
+	bad[33] = 5
+	if _, err := ParsePublicKey(bad); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	var signature [64]byte
+	copy(signature[:32], IntToByte(Curve.Gx))
+	copy(signature[32:], IntToByte(One))
+	if ret, err := ParseSignature(signature[:]); err != nil || ret != signature {
+		t.Fatal("parse signature failed")
+	}
+	if _, err := ParseSignature(signature[:63]); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	r := signature
+	copy(r[:32], IntToByte(Curve.P))
+	if _, err := ParseSignature(r[:]); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	s := signature
+	copy(s[32:], IntToByte(Curve.N))
+	if _, err := ParseSignature(s[:]); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := Verify([33]byte{2}, []byte("msg"), signature); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	message := []byte("test msg")
+	d1, P1 := GenKey()
+	d2, P2 := GenKey()
+	k1, R1, _ := GenNonce(d1, message, nil)
+	k2, R2, _ := GenNonce(d2, message, nil)
+	priv1 := &PrivateKey{D: d1, K0: k1}
+	priv2 := &PrivateKey{D: d2, K0: k2}
+	publicKeys := []*PublicKey{{P: P1, R: R1}, {P: P2, R: R2}}
+
+	var zero [32]byte
+	cases := []struct {
+		name string
+		err  error
+		fn   func() error
+	}{
+		{"sign without keys", ErrInvalidLength, func() error {
+			_, _, _, err := Sign(message, priv1, nil)
+			return err
+		}},
+		{"sign with nil private key", ErrScalarOutOfRange, func() error {
+			_, _, _, err := Sign(message, nil, publicKeys)
+			return err
+		}},
+		{"sign with zero nonce", ErrScalarOutOfRange, func() error {
+			_, _, _, err := Sign(message, &PrivateKey{D: d1, K0: zero}, publicKeys)
+			return err
+		}},
+		{"sign with invalid nonce point", ErrInvalidPoint, func() error {
+			_, _, _, err := Sign(message, priv1, []*PublicKey{{P: P1, R: R1}, {P: P2}})
+			return err
+		}},
+		{"sign with nil public key", ErrInvalidPoint, func() error {
+			_, _, _, err := Sign(message, priv1, []*PublicKey{publicKeys[0], nil})
+			return err
+		}},
+		{"sign outside set", ErrKeyNotInSet, func() error {
+			_, _, _, err := Sign(message, priv2, publicKeys[:1])
+			return err
+		}},
+		{"append with invalid index", ErrInvalidIndex, func() error {
+			_, err := AppendSignature([64]byte{}, message, priv1, publicKeys, 2)
+			return err
+		}},
+		{"append twice", ErrAlreadySigned, func() error {
+			_, err := AppendSignatureSigned([64]byte{}, message, priv1, publicKeys, []int{0})
+			return err
+		}},
+		{"verify sign input without signers", ErrInvalidLength, func() error {
+			_, err := VerifySignInput(nil, publicKeys, message, [64]byte{})
+			return err
+		}},
+		{"verify sign input with duplicate index", ErrDuplicateIndex, func() error {
+			_, err := VerifySignInputSigned([]int{1, 1}, publicKeys, message, [64]byte{})
+			return err
+		}},
+		{"multi verify without keys", ErrInvalidLength, func() error {
+			_, err := MultiVerify(nil, message, [64]byte{})
+			return err
+		}},
+		{"multi verify with invalid key", ErrInvalidPoint, func() error {
+			_, err := MultiVerify([][33]byte{P1, {}}, message, [64]byte{})
+			return err
+		}},
+		{"aggregate to infinity", ErrInvalidPoint, func() error {
+			neg := P1
+			neg[0] ^= 1
+			_, err := MultiVerify([][33]byte{P1, neg}, message, [64]byte{})
+			return err
+		}},
+		{"gen nonce with zero key", ErrScalarOutOfRange, func() error {
+			_, _, err := GenNonce(zero, message, nil)
+			return err
+		}},
+		{"adapt with invalid nonce", ErrInvalidPoint, func() error {
+			_, err := Adapt([65]byte{}, d1)
+			return err
+		}},
+		{"subgroup with wrong keys", ErrInvalidBitmap, func() error {
+			bitmap, _ := NewBitmap(2, 0)
+			_, err := MultiVerify([][33]byte{P2}, message, [64]byte{}, WithSubgroup([][33]byte{P1, P2}, bitmap))
+			return err
+		}},
+	}
+	for _, c := range cases {
+		if err := c.fn(); !errors.Is(err, c.err) {
+			t.Fatalf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+
+	signature, err := AppendSignature([64]byte{}, message, priv1, publicKeys, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err = AppendSignature(signature, message, priv2, publicKeys, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature[63] ^= 1
+	if _, err := MultiVerify([][33]byte{P1, P2}, message, signature); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
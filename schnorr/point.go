@@ -0,0 +1,157 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Point secp256k1 上的点，零值为无穷远点
+// 加法使用完备公式，BaseMul 和 Mul 的运行时间不依赖标量，可以直接用于私钥和随机数
+// 方法的接收者保存结果并返回自己，例如 p.Add(a, b) 计算 p = a + b
+type Point struct {
+	p projectivePoint
+}
+
+// Generator 生成元 G
+func Generator() *Point {
+	G := basePoint()
+	p := new(Point)
+	p.p.setJacobian(&G)
+	return p
+}
+
+// point 内部的射影坐标，零值 (0, 0, 0) 当作无穷远点 (0, 1, 0)
+// secp256k1 上没有 y = 0 的点，Y 为0只可能是零值
+func (p *Point) point() projectivePoint {
+	q := p.p
+	y := q.y[0] | q.y[1] | q.y[2] | q.y[3]
+	q.y.cmov(&fieldOne, 1^((y|-y)>>63))
+	return q
+}
+
+// SetBytes 由33字节压缩公钥设置，编码不合法时返回 ErrInvalidPoint，p 不变
+func (p *Point) SetBytes(b []byte) (*Point, error) {
+	x, y := Unmarshal(Curve, b)
+	if x == nil {
+		return nil, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	return p.SetCoordinates(x, y)
+}
+
+// Bytes 33字节压缩格式，同 Marshal
+// 无穷远点没有压缩格式，结果和 Marshal(Curve, 0, 0) 相同，SetBytes 不接受
+func (p *Point) Bytes() (b [33]byte) {
+	x, y := p.Coordinates()
+	copy(b[:], Marshal(Curve, x, y))
+	return b
+}
+
+// SetCoordinates 由 big.Int 表示的仿射坐标设置，和 Curve 一样用 (0, 0) 表示无穷远点
+// 不在曲线上时返回 ErrInvalidPoint，p 不变
+func (p *Point) SetCoordinates(x, y *big.Int) (*Point, error) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		p.p = projectivePoint{y: fieldOne}
+		return p, nil
+	}
+	if x.Sign() < 0 || y.Sign() < 0 || x.Cmp(Curve.P) >= 0 || y.Cmp(Curve.P) >= 0 || !Curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("%w: point is not on curve", ErrInvalidPoint)
+	}
+	var fx, fy fieldElement
+	fx.setBig(x)
+	fy.setBig(y)
+	p.p = projectivePoint{x: fx, y: fy, z: fieldOne}
+	return p, nil
+}
+
+// Coordinates 仿射坐标，无穷远点为 (0, 0)，可以直接传给 Curve 和 Marshal
+func (p *Point) Coordinates() (x, y *big.Int) {
+	q := p.point()
+	return q.big()
+}
+
+// Set p = a
+func (p *Point) Set(a *Point) *Point {
+	p.p = a.point()
+	return p
+}
+
+// Add p = a + b
+func (p *Point) Add(a, b *Point) *Point {
+	A, B := a.point(), b.point()
+	p.p.add(&A, &B)
+	return p
+}
+
+// Sub p = a - b
+func (p *Point) Sub(a, b *Point) *Point {
+	var nb Point
+	return p.Add(a, nb.Neg(b))
+}
+
+// Neg p = -a
+func (p *Point) Neg(a *Point) *Point {
+	p.p = a.point()
+	p.p.y.neg(&p.p.y)
+	return p
+}
+
+// BaseMul p = k*G，使用预先计算的表
+func (p *Point) BaseMul(k *Scalar) *Point {
+	p.p = baseMultPoint(&k.s)
+	return p
+}
+
+// Mul p = k*a
+// 4位固定窗口，每次取表都读出整个表，用 cmov 选出需要的点
+func (p *Point) Mul(k *Scalar, a *Point) *Point {
+	var table [16]projectivePoint
+	table[0] = projectivePoint{y: fieldOne}
+	table[1] = a.point()
+	for j := 2; j < 16; j++ {
+		table[j].add(&table[j-1], &table[1])
+	}
+	acc := projectivePoint{y: fieldOne}
+	for i := 63; i >= 0; i-- {
+		acc.double(&acc)
+		acc.double(&acc)
+		acc.double(&acc)
+		acc.double(&acc)
+		digit := (k.s[i/16] >> (4 * uint(i%16))) & 15
+		var t projectivePoint
+		for j := 0; j < 16; j++ {
+			d := digit ^ uint64(j)
+			t.cmov(&table[j], ((d-1)>>63)&1)
+		}
+		acc.add(&acc, &t)
+	}
+	p.p = acc
+	return p
+}
+
+// Equal 判断 p == a，X1*Z2 == X2*Z1 且 Y1*Z2 == Y2*Z1
+func (p *Point) Equal(a *Point) bool {
+	P, A := p.point(), a.point()
+	var l, r fieldElement
+	l.mul(&P.x, &A.z)
+	r.mul(&A.x, &P.z)
+	x := l.equal(&r)
+	l.mul(&P.y, &A.z)
+	r.mul(&A.y, &P.z)
+	return x && l.equal(&r)
+}
+
+// IsInfinity 判断 p 是否是无穷远点
+func (p *Point) IsInfinity() bool {
+	q := p.point()
+	return q.z.isZero()
+}
+
+// NewSignature 由随机数点 R 和 s 组成签名 Rx||s
+// 多人分别签名时，R 为所有人 R 的和，s 为所有人 s 的和
+func NewSignature(R *Point, s *Scalar) (signature [64]byte) {
+	x, _ := R.Coordinates()
+	copy(signature[:32], IntToByte(x))
+	b := s.Bytes()
+	copy(signature[32:], b[:])
+	return signature
+}
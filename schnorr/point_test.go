@@ -0,0 +1,157 @@
+package schnorr
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPointArithmetic(t *testing.T) {
+	G := Generator()
+	for i := 0; i < 20; i++ {
+		a, err := RandomScalar()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := RandomScalar()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ab := a.Bytes()
+		bb := b.Bytes()
+		A := new(Point).BaseMul(a)
+		ax, ay := Curve.ScalarBaseMult(ab[:])
+		if x, y := A.Coordinates(); x.Cmp(ax) != 0 || y.Cmp(ay) != 0 {
+			t.Fatal("BaseMul mismatch")
+		}
+		// Mul 和 Curve.ScalarMult 一致
+		AB := new(Point).Mul(b, A)
+		ex, ey := Curve.ScalarMult(ax, ay, bb[:])
+		if x, y := AB.Coordinates(); x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatal("Mul mismatch")
+		}
+		// a*G + b*G = (a+b)*G
+		B := new(Point).BaseMul(b)
+		sum := new(Point).Add(A, B)
+		if !sum.Equal(new(Point).BaseMul(new(Scalar).Add(a, b))) {
+			t.Fatal("Add mismatch")
+		}
+		// a*G - b*G = (a-b)*G
+		if !new(Point).Sub(A, B).Equal(new(Point).BaseMul(new(Scalar).Sub(a, b))) {
+			t.Fatal("Sub mismatch")
+		}
+		// A + A 走加法公式里相同点的情况
+		if !new(Point).Add(A, A).Equal(new(Point).Mul(new(Scalar).SetBig(Two), A)) {
+			t.Fatal("doubling mismatch")
+		}
+		// (a*b)*G = b*(a*G)
+		if !AB.Equal(new(Point).BaseMul(new(Scalar).Mul(a, b))) {
+			t.Fatal("Mul mismatch")
+		}
+		// a * a^-1 = 1
+		if !new(Scalar).Mul(a, new(Scalar).Inverse(a)).Equal(new(Scalar).SetBig(One)) {
+			t.Fatal("Inverse mismatch")
+		}
+		if !new(Point).Add(A, new(Point).Neg(A)).IsInfinity() {
+			t.Fatal("A - A should be infinity")
+		}
+		// 序列化
+		encoded := A.Bytes()
+		decoded, err := new(Point).SetBytes(encoded[:])
+		if err != nil || !decoded.Equal(A) {
+			t.Fatal("SetBytes failed")
+		}
+		if !G.Equal(new(Point).Mul(new(Scalar).SetBig(One), G)) {
+			t.Fatal("1*G should be G")
+		}
+	}
+}
+
+func TestPointInfinity(t *testing.T) {
+	var zero Point
+	var s Scalar
+	G := Generator()
+	if !zero.IsInfinity() || G.IsInfinity() {
+		t.Fatal("IsInfinity failed")
+	}
+	if !new(Point).Add(&zero, G).Equal(G) || !new(Point).Add(G, &zero).Equal(G) {
+		t.Fatal("O + G should be G")
+	}
+	if !new(Point).BaseMul(&s).IsInfinity() || !new(Point).Mul(&s, G).IsInfinity() {
+		t.Fatal("0*G should be infinity")
+	}
+	if x, y := zero.Coordinates(); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("infinity should be (0, 0)")
+	}
+	if zero.Equal(G) || G.Equal(&zero) || !zero.Equal(new(Point).Neg(&zero)) {
+		t.Fatal("Equal failed")
+	}
+	encoded := zero.Bytes()
+	if _, err := new(Point).SetBytes(encoded[:]); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatal("infinity should not be decoded")
+	}
+	if _, err := new(Point).SetCoordinates(One, One); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatal("point is not on curve")
+	}
+}
+
+func TestScalarBytes(t *testing.T) {
+	var b [32]byte
+	copy(b[:], IntToByte(Curve.N))
+	if _, err := NewScalar(b); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Fatal("N should be out of range")
+	}
+	copy(b[:], IntToByte(new(big.Int).Sub(Curve.N, One)))
+	s, err := NewScalar(b)
+	if err != nil || s.Bytes() != b {
+		t.Fatal("NewScalar failed")
+	}
+	if !new(Scalar).Add(s, new(Scalar).SetBig(One)).IsZero() {
+		t.Fatal("N-1 + 1 should be 0")
+	}
+	if new(Scalar).Neg(s).Big().Cmp(One) != 0 {
+		t.Fatal("-(N-1) should be 1")
+	}
+}
+
+func TestSignatureFromParts(t *testing.T) {
+	// 两个人分别签名，用 Point 和 Scalar 聚合
+	message := []byte("test msg")
+	var privateKeys []*PrivateKey
+	var publicKeys []*PublicKey
+	for i := 0; i < 2; i++ {
+		d, P := GenKey()
+		k0, R, err := GenNonce(d, message, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKeys = append(privateKeys, &PrivateKey{D: d, K0: k0})
+		publicKeys = append(publicKeys, &PublicKey{P: P, R: R})
+	}
+	var R Point
+	var s Scalar
+	for _, privateKey := range privateKeys {
+		Rx, Ry, si, err := Sign(message, privateKey, publicKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		RI, err := new(Point).SetCoordinates(Rx, Ry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		R.Add(&R, RI)
+		s.Add(&s, new(Scalar).SetBig(si))
+	}
+	signature := NewSignature(&R, &s)
+	if ret, err := MultiVerify([][33]byte{publicKeys[0].P, publicKeys[1].P}, message, signature); err != nil || !ret {
+		t.Fatal("signature verification failed")
+	}
+}
+
+func BenchmarkPointMul(b *testing.B) {
+	k, _ := RandomScalar()
+	P := Generator()
+	for i := 0; i < b.N; i++ {
+		P.Mul(k, P)
+	}
+}
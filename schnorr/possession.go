@@ -0,0 +1,50 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const possessionTag = "schnorr-go/pop"
+
+// ProvePossession 证明持有公钥 P = d*G 对应的私钥 d (proof of possession)
+// 每个参与者在登记公钥时提供一次，其他人检查通过之后就可以放心地直接相加聚合公钥
+// proof = (Rx, s), s = k + e*d, e = TaggedHash("schnorr-go/pop", Rx||P) mod N
+// e 的计算方式和普通签名不同，proof 不能被当作任何消息的签名使用，反之亦然
+func ProvePossession(d [32]byte) (proof [64]byte, err error) {
+	var aux [32]byte
+	if _, err = rand.Read(aux[:]); err != nil {
+		return proof, err
+	}
+	k0, R, err := GenNonce(d, []byte(possessionTag), aux[:])
+	if err != nil {
+		return proof, err
+	}
+	Rx, Ry := Unmarshal(Curve, R[:])
+	Px, Py := ScalarBaseMult(d)
+	e := getPossessionE(Px, Py, IntToByte(Rx))
+	s := response(&k0, &d, big.Jacobi(Ry, Curve.P) != 1, e)
+
+	copy(proof[:32], IntToByte(Rx))
+	copy(proof[32:], IntToByte(s))
+	return proof, nil
+}
+
+// VerifyPossession 验证公钥 P 的持有证明
+func VerifyPossession(P [33]byte, proof [64]byte) (bool, error) {
+	if _, _, err := parsePublicKey(P); err != nil {
+		return false, err
+	}
+	ret, err := verify(P, proof, getPossessionE)
+	if err != nil || !ret {
+		return false, fmt.Errorf("%w: proof of possession", ErrVerificationFailed)
+	}
+	return true, nil
+}
+
+func getPossessionE(Px, Py *big.Int, rX []byte) *big.Int {
+	h := TaggedHash(possessionTag, rX, Marshal(Curve, Px, Py))
+	e := new(big.Int).SetBytes(h[:])
+	return e.Mod(e, Curve.N)
+}
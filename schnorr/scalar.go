@@ -0,0 +1,289 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"math/bits"
+)
+
+// scalar 曲线阶 N 上的标量 mod N
+// 4个64位的limb，小端序，总是保持 < N
+// 和 fieldElement 一样，所有运算不依赖数值分支，用于私钥和随机数的计算
+type scalar [4]uint64
+
+var (
+	scalarN = scalar{0xBFD25E8CD0364141, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+	// scalarR2 = 2^512 mod N，用于转换到 Montgomery 形式
+	scalarR2 = scalar{0x896CF21467D7D140, 0x741496C20E7CF878, 0xE697F5E45BCD07C6, 0x9D671CD581C69BC5}
+)
+
+// scalarN0 = -N^-1 mod 2^64
+const scalarN0 = 0x4B0DFF665588B13F
+
+// setBytes 由32字节大端序设置并规约到 [0, N)，返回值为1表示原来的值 >= N
+func (s *scalar) setBytes(b *[32]byte) uint64 {
+	for i := 0; i < 4; i++ {
+		s[i] = uint64(b[31-8*i]) | uint64(b[30-8*i])<<8 | uint64(b[29-8*i])<<16 | uint64(b[28-8*i])<<24 |
+			uint64(b[27-8*i])<<32 | uint64(b[26-8*i])<<40 | uint64(b[25-8*i])<<48 | uint64(b[24-8*i])<<56
+	}
+	// 2^256 < 2N，最多减一次
+	t, borrow := s.subN()
+	s.cmov(&t, borrow^1)
+	return borrow ^ 1
+}
+
+// setBig 由 big.Int 设置，只用于公开的值
+func (s *scalar) setBig(i *big.Int) *scalar {
+	b := [32]byte{}
+	copy(b[:], IntToByte(new(big.Int).Mod(i, Curve.N)))
+	s.setBytes(&b)
+	return s
+}
+
+// bytes 32字节大端序
+func (s *scalar) bytes() (b [32]byte) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-8*i-j] = byte(s[i] >> (8 * j))
+		}
+	}
+	return b
+}
+
+func (s *scalar) big() *big.Int {
+	b := s.bytes()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// subN 计算 s - N，borrow 为1表示 s < N
+func (s *scalar) subN() (r scalar, borrow uint64) {
+	r[0], borrow = bits.Sub64(s[0], scalarN[0], 0)
+	r[1], borrow = bits.Sub64(s[1], scalarN[1], borrow)
+	r[2], borrow = bits.Sub64(s[2], scalarN[2], borrow)
+	r[3], borrow = bits.Sub64(s[3], scalarN[3], borrow)
+	return r, borrow
+}
+
+// cmov flag 为1时 s = a，否则不变
+func (s *scalar) cmov(a *scalar, flag uint64) {
+	mask := -flag
+	s[0] ^= mask & (s[0] ^ a[0])
+	s[1] ^= mask & (s[1] ^ a[1])
+	s[2] ^= mask & (s[2] ^ a[2])
+	s[3] ^= mask & (s[3] ^ a[3])
+}
+
+// isZero 为0时返回1，否则返回0
+func (s *scalar) isZero() uint64 {
+	x := s[0] | s[1] | s[2] | s[3]
+	return 1 ^ ((x | -x) >> 63)
+}
+
+// add s = a + b
+func (s *scalar) add(a, b *scalar) *scalar {
+	var c uint64
+	s[0], c = bits.Add64(a[0], b[0], 0)
+	s[1], c = bits.Add64(a[1], b[1], c)
+	s[2], c = bits.Add64(a[2], b[2], c)
+	s[3], c = bits.Add64(a[3], b[3], c)
+	// 溢出或者 >= N 时减去 N
+	t, borrow := s.subN()
+	s.cmov(&t, c|(borrow^1))
+	return s
+}
+
+// neg s = -a，a 为0时结果为0
+func (s *scalar) neg(a *scalar) *scalar {
+	var r scalar
+	var borrow uint64
+	r[0], borrow = bits.Sub64(scalarN[0], a[0], 0)
+	r[1], borrow = bits.Sub64(scalarN[1], a[1], borrow)
+	r[2], borrow = bits.Sub64(scalarN[2], a[2], borrow)
+	r[3], _ = bits.Sub64(scalarN[3], a[3], borrow)
+	var zero scalar
+	r.cmov(&zero, a.isZero())
+	*s = r
+	return s
+}
+
+// condNeg flag 为1时 s = -s
+func (s *scalar) condNeg(flag uint64) *scalar {
+	var r scalar
+	r.neg(s)
+	s.cmov(&r, flag)
+	return s
+}
+
+// sub s = a - b
+func (s *scalar) sub(a, b *scalar) *scalar {
+	var nb scalar
+	nb.neg(b)
+	return s.add(a, &nb)
+}
+
+// mul s = a * b，两次 Montgomery 乘法: (a*b/R) * R^2 / R
+func (s *scalar) mul(a, b *scalar) *scalar {
+	t := montMul(a, b)
+	*s = montMul(&t, &scalarR2)
+	return s
+}
+
+// montMul 计算 a*b/2^256 mod N (CIOS)
+func montMul(a, b *scalar) scalar {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		// t = t + a[i]*b
+		var c uint64
+		c, t[0] = mac(a[i], b[0], t[0], 0)
+		c, t[1] = mac(a[i], b[1], t[1], c)
+		c, t[2] = mac(a[i], b[2], t[2], c)
+		c, t[3] = mac(a[i], b[3], t[3], c)
+		t[4], c = bits.Add64(t[4], c, 0)
+		t[5] = c
+		// t = (t + m*N) / 2^64，m 使最低的limb为0
+		m := t[0] * scalarN0
+		c, _ = mac(m, scalarN[0], t[0], 0)
+		c, t[0] = mac(m, scalarN[1], t[1], c)
+		c, t[1] = mac(m, scalarN[2], t[2], c)
+		c, t[2] = mac(m, scalarN[3], t[3], c)
+		t[3], c = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + c
+	}
+	// t < 2N
+	r := scalar{t[0], t[1], t[2], t[3]}
+	sub, borrow := r.subN()
+	r.cmov(&sub, t[4]|(borrow^1))
+	return r
+}
+
+// inv s = a^(N-2) = 1/a，a 为0时结果为0
+// 指数是公开的，按位平方乘的运行时间只和 N 有关
+func (s *scalar) inv(a *scalar) *scalar {
+	e := new(big.Int).Sub(Curve.N, Two)
+	r := scalar{1, 0, 0, 0}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r.mul(&r, &r)
+		if e.Bit(i) == 1 {
+			r.mul(&r, a)
+		}
+	}
+	*s = r
+	return s
+}
+
+// response 计算签名的 s = ±k0 + e*d mod N，negate 为 true 时使用 -k0
+// k0 和 d 是秘密的，只参与固定时间的运算；e 和 negate 是公开的
+func response(k0, d *[32]byte, negate bool, e *big.Int) *big.Int {
+	var k, x, ed scalar
+	k.setBytes(k0)
+	x.setBytes(d)
+	var flag uint64
+	if negate {
+		flag = 1
+	}
+	k.condNeg(flag)
+	ed.setBig(e)
+	ed.mul(&ed, &x)
+	k.add(&k, &ed)
+	return k.big()
+}
+
+// Scalar 曲线阶 N 上的标量，零值为0
+// 运算和内部的签名计算一样是固定时间的，可以保存私钥、随机数和部分签名
+// 方法的接收者保存结果并返回自己，例如 s.Add(a, b) 计算 s = a + b
+type Scalar struct {
+	s scalar
+}
+
+// NewScalar 由32字节大端序创建标量，b >= N 时返回 ErrScalarOutOfRange
+func NewScalar(b [32]byte) (*Scalar, error) {
+	return new(Scalar).SetBytes(b)
+}
+
+// RandomScalar 生成 [1, N) 之间的随机标量
+func RandomScalar() (*Scalar, error) {
+	var b [32]byte
+	s := new(Scalar)
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		if s.s.setBytes(&b)|s.s.isZero() == 0 {
+			return s, nil
+		}
+	}
+}
+
+// SetBytes 由32字节大端序设置，b >= N 时返回 ErrScalarOutOfRange，s 不变
+func (s *Scalar) SetBytes(b [32]byte) (*Scalar, error) {
+	var t scalar
+	if t.setBytes(&b) != 0 {
+		return nil, ErrScalarOutOfRange
+	}
+	s.s = t
+	return s, nil
+}
+
+// SetBig 由 big.Int 设置，i 先规约到 [0, N)，只应该用于公开的值(例如 Challenge 的结果)
+func (s *Scalar) SetBig(i *big.Int) *Scalar {
+	s.s.setBig(i)
+	return s
+}
+
+// Bytes 32字节大端序
+func (s *Scalar) Bytes() [32]byte {
+	return s.s.bytes()
+}
+
+// Big 转换成 big.Int
+func (s *Scalar) Big() *big.Int {
+	return s.s.big()
+}
+
+// Set s = a
+func (s *Scalar) Set(a *Scalar) *Scalar {
+	s.s = a.s
+	return s
+}
+
+// Add s = a + b mod N
+func (s *Scalar) Add(a, b *Scalar) *Scalar {
+	s.s.add(&a.s, &b.s)
+	return s
+}
+
+// Sub s = a - b mod N
+func (s *Scalar) Sub(a, b *Scalar) *Scalar {
+	s.s.sub(&a.s, &b.s)
+	return s
+}
+
+// Neg s = -a mod N
+func (s *Scalar) Neg(a *Scalar) *Scalar {
+	s.s.neg(&a.s)
+	return s
+}
+
+// Mul s = a * b mod N
+func (s *Scalar) Mul(a, b *Scalar) *Scalar {
+	s.s.mul(&a.s, &b.s)
+	return s
+}
+
+// Inverse s = 1/a mod N，a 为0时结果为0
+func (s *Scalar) Inverse(a *Scalar) *Scalar {
+	s.s.inv(&a.s)
+	return s
+}
+
+// Equal 判断 s == a，比较不依赖数值分支
+func (s *Scalar) Equal(a *Scalar) bool {
+	var d scalar
+	d.sub(&s.s, &a.s)
+	return d.isZero() == 1
+}
+
+// IsZero 判断 s == 0
+func (s *Scalar) IsZero() bool {
+	return s.s.isZero() == 1
+}
@@ -0,0 +1,124 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestScalarArithmetic(t *testing.T) {
+	N := Curve.N
+	edge := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), new(big.Int).Sub(N, One), new(big.Int).Sub(N, Two),
+		new(big.Int).Lsh(One, 255), new(big.Int).Rsh(N, 1)}
+	var values []*big.Int
+	values = append(values, edge...)
+	for i := 0; i < 50; i++ {
+		v, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	for _, x := range values {
+		for _, y := range values {
+			a := new(scalar).setBig(x)
+			b := new(scalar).setBig(y)
+			check := func(op string, got *scalar, expected *big.Int) {
+				expected.Mod(expected, N)
+				if got.big().Cmp(expected) != 0 {
+					t.Fatalf("%s(%x, %x) = %x, expected %x", op, x, y, got.big(), expected)
+				}
+			}
+			check("add", new(scalar).add(a, b), new(big.Int).Add(x, y))
+			check("sub", new(scalar).sub(a, b), new(big.Int).Sub(x, y))
+			check("mul", new(scalar).mul(a, b), new(big.Int).Mul(x, y))
+		}
+		a := new(scalar).setBig(x)
+		if new(scalar).neg(a).big().Cmp(new(big.Int).Mod(new(big.Int).Neg(x), N)) != 0 {
+			t.Fatal("neg failed")
+		}
+		if (a.isZero() == 1) != (x.Sign() == 0) {
+			t.Fatal("isZero failed")
+		}
+		if x.Sign() != 0 && new(scalar).inv(a).big().Cmp(new(big.Int).ModInverse(x, N)) != 0 {
+			t.Fatal("inv failed")
+		}
+	}
+
+	// setBytes 规约 >= N 的值
+	for _, v := range []*big.Int{N, new(big.Int).Add(N, One), new(big.Int).Sub(new(big.Int).Lsh(One, 256), One)} {
+		var b [32]byte
+		copy(b[:], IntToByte(v))
+		var s scalar
+		if s.setBytes(&b) != 1 || s.big().Cmp(new(big.Int).Mod(v, N)) != 0 {
+			t.Fatalf("setBytes(%x) failed", v)
+		}
+	}
+}
+
+func TestScalarBaseMult(t *testing.T) {
+	N := Curve.N
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), big.NewInt(15), big.NewInt(16),
+		new(big.Int).Sub(N, One), N, new(big.Int).Sub(new(big.Int).Lsh(One, 256), One)}
+	for i := 0; i < 50; i++ {
+		v, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	for _, v := range values {
+		var k [32]byte
+		copy(k[:], IntToByte(v))
+		x, y := ScalarBaseMult(k)
+		ex, ey := Curve.ScalarBaseMult(IntToByte(new(big.Int).Mod(v, N)))
+		if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+			t.Fatalf("ScalarBaseMult(%x) mismatch", v)
+		}
+	}
+}
+
+func TestProjectiveAddComplete(t *testing.T) {
+	G := basePoint()
+	var P, Q, inf projectivePoint
+	P.setJacobian(&G)
+	inf.setJacobian(&jacobianPoint{})
+	neg := G
+	neg.neg(&neg)
+	Q.setJacobian(&neg)
+
+	var r projectivePoint
+	// P + P
+	x, y := r.add(&P, &P).big()
+	ex, ey := Curve.Double(Curve.Gx, Curve.Gy)
+	if x.Cmp(ex) != 0 || y.Cmp(ey) != 0 {
+		t.Fatal("doubling failed")
+	}
+	// P + (-P) = O
+	if x, y := r.add(&P, &Q).big(); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("P + (-P) should be infinity")
+	}
+	// O + P = P, O + O = O
+	if x, y := r.add(&inf, &P).big(); x.Cmp(Curve.Gx) != 0 || y.Cmp(Curve.Gy) != 0 {
+		t.Fatal("O + P failed")
+	}
+	if x, y := r.add(&inf, &inf).big(); x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatal("O + O failed")
+	}
+}
+
+func BenchmarkScalarBaseMult(b *testing.B) {
+	var k [32]byte
+	rand.Read(k[:])
+	for i := 0; i < b.N; i++ {
+		ScalarBaseMult(k)
+	}
+}
+
+func BenchmarkScalarMul(b *testing.B) {
+	x, _ := rand.Int(rand.Reader, Curve.N)
+	s := new(scalar).setBig(x)
+	for i := 0; i < b.N; i++ {
+		s.mul(s, s)
+	}
+}
@@ -1,7 +1,7 @@
 package schnorr
 
 import (
-	"errors"
+	"fmt"
 	"math/big"
 )
 
@@ -23,35 +23,64 @@ type PublicKey struct {
 // message是签名消息
 // publicKeys 是公钥的集合，按照签名顺序排序
 // index 当前签名的序号，小于index的已经签完
-func AppendSignature(signInput [64]byte, message []byte, privateKey *PrivateKey, publicKeys []*PublicKey, index int) (signOutput [64]byte, err error) {
+// opts 可选配置，例如 WithAggregation(AggregationHardened)
+func AppendSignature(signInput [64]byte, message []byte, privateKey *PrivateKey, publicKeys []*PublicKey, index int, opts ...Option) (signOutput [64]byte, err error) {
 	//校验privateKey
 	if index >= len(publicKeys) || index < 0{
-		return signOutput, errors.New("invalid index")
+		return signOutput, ErrInvalidIndex
 	}
+	signed := make([]int, index)
+	for i := range signed {
+		signed[i] = i
+	}
+	return appendSignature(signInput, message, privateKey, publicKeys, signed, opts...)
+}
 
-	RxSigned, RySigned := Zero, Zero
-	if index > 0 {
-		ret, err := VerifySignInput(publicKeys[:index], publicKeys, message, signInput)
-		if err != nil {
-			return signOutput, err
-		}
-		if !ret {
-			return signOutput, errors.New("signature verification failed")
-		}
-		pubSigned := aggregationPublicKey(publicKeys[:index])
-		RxSigned, RySigned = Unmarshal(Curve, pubSigned.R[:])
+// AppendSignatureSigned 和 AppendSignature 相同，但是参与者可以按任意顺序签名
+// signed 是已经签过的参与者在 publicKeys 中的序号(顺序无关)，signInput 是他们的签名结果
+// 自己不能在 signed 中；下一个参与者使用的 signed 为本次的 signed 加上自己的序号
+func AppendSignatureSigned(signInput [64]byte, message []byte, privateKey *PrivateKey, publicKeys []*PublicKey, signed []int, opts ...Option) (signOutput [64]byte, err error) {
+	return appendSignature(signInput, message, privateKey, publicKeys, signed, opts...)
+}
+
+// VerifySignInputSigned 验证 signed 中的参与者签名的中间结果
+func VerifySignInputSigned(signed []int, publicKeys []*PublicKey, message []byte, signInput [64]byte, opts ...Option) (bool, error) {
+	ctx, nonces, err := newContext(publicKeys, opts)
+	if err != nil {
+		return false, err
 	}
+	return ctx.VerifySignInput(signed, nonces, message, signInput)
+}
 
-	Rix, Riy, s, err := Sign(message, privateKey, publicKeys)
-	if index > 0 {
-		Rix, Riy = Curve.Add(RxSigned, RySigned, Rix, Riy)
-		sSigned := new(big.Int).SetBytes(signInput[32:])
-		s = s.Add(s, sSigned)
-		s = s.Mod(s, Curve.N)
-	}
-	copy(signOutput[:32], IntToByte(Rix))
-	copy(signOutput[32:], IntToByte(s))
-	return  signOutput, nil
+// appendSignature 在 signed 中的参与者的签名结果上追加自己的签名
+func appendSignature(signInput [64]byte, message []byte, privateKey *PrivateKey, publicKeys []*PublicKey, signed []int, opts ...Option) (signOutput [64]byte, err error) {
+	if err := checkPrivateKey(privateKey); err != nil {
+		return signOutput, err
+	}
+	ctx, nonces, err := newContext(publicKeys, opts)
+	if err != nil {
+		return signOutput, err
+	}
+	return ctx.AppendSignature(signInput, message, privateKey, nonces, signed)
+}
+
+// newContext 由 publicKeys 中的 P 创建 KeyAggContext，同时返回每个参与者的 R
+func newContext(publicKeys []*PublicKey, opts []Option) (*KeyAggContext, [][33]byte, error) {
+	if len(publicKeys) == 0 {
+		return nil, nil, fmt.Errorf("%w: empty publicKeys", ErrInvalidLength)
+	}
+	nonces := make([][33]byte, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		if publicKey == nil {
+			return nil, nil, fmt.Errorf("%w: nil public key", ErrInvalidPoint)
+		}
+		nonces[i] = publicKey.R
+	}
+	ctx, err := NewKeyAggContext(publicKeysP(publicKeys), opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, nonces, nil
 }
 
 // Sign 一个参与者签名
@@ -59,66 +88,52 @@ func AppendSignature(signInput [64]byte, message []byte, privateKey *PrivateKey,
 // publicKey是公钥
 // message是签名消息
 // publicKeys 是公钥的集合
-func Sign(message []byte, privateKey *PrivateKey, publicKeys []*PublicKey) (RIx, RIy, s *big.Int, err error){
-	//校验privateKey 在publicKeys里
-	if !checkPublicInArray(privateKey, publicKeys) {
-		return nil,nil, nil, errors.New("privateKey is not in array")
+// s = k + e*a*d, a 是自己公钥的聚合系数
+func Sign(message []byte, privateKey *PrivateKey, publicKeys []*PublicKey, opts ...Option) (RIx, RIy, s *big.Int, err error){
+	if err := checkPrivateKey(privateKey); err != nil {
+		return nil, nil, nil, err
 	}
-
-	// 求聚合公钥
-	pub := aggregationPublicKey(publicKeys)
-	Px, Py := Unmarshal(Curve, pub.P[:])
-	Rx, Ry := Unmarshal(Curve, pub.R[:])
-	//Bip32分散k0
-	RIx, RIy = Curve.ScalarBaseMult(privateKey.K0[:])
-
-	k0 := new(big.Int).SetBytes(privateKey.K0[:])
-	k := getK(Ry, k0)
-
-	rX := IntToByte(Rx)
-	e := getE(Px, Py, rX, message)
-	// s = k + de
-	priKey := new(big.Int).SetBytes(privateKey.D[:])
-	e.Mul(e, priKey)
-	k.Add(k, e)
-	k.Mod(k, Curve.N)
-
-	return RIx, RIy, k,nil
+	ctx, nonces, err := newContext(publicKeys, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ctx.Sign(message, privateKey, nonces)
 }
 
 //Verify
 func Verify(publicKey [33]byte, message []byte, signature [64]byte) (bool, error) {
-	Px, Py := Unmarshal(Curve, publicKey[:])
+	return verify(publicKey, signature, func(Px, Py *big.Int, rX []byte) *big.Int {
+		return getE(Px, Py, rX, message)
+	})
+}
 
-	if Px == nil || Py == nil || !Curve.IsOnCurve(Px, Py) {
-		return false, errors.New("signature verification failed")
+// verify 验证 R' = s*G - e*P 的x坐标等于r，且Ry'是p的二次剩余
+// challenge 计算 e
+func verify(publicKey [33]byte, signature [64]byte, challenge func(Px, Py *big.Int, rX []byte) *big.Int) (bool, error) {
+	P, ok := decompress(publicKey[:])
+	if !ok {
+		return false, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
 	}
-	r := new(big.Int).SetBytes(signature[:32])
-	if r.Cmp(Curve.P) >= 0 {
-		return false, errors.New("r is larger than or equal to field size")
+	r, s, err := parseSignature(signature)
+	if err != nil {
+		return false, err
 	}
-	s := new(big.Int).SetBytes(signature[32:])
-	if s.Cmp(Curve.N) >= 0 {
-		return false, errors.New("s is larger than or equal to curve order")
-	}
-
-	e := getE(Px, Py, IntToByte(r), message)
-	sGx, sGy := Curve.ScalarBaseMult(IntToByte(s))
-	// e.Sub(Curve.N, e)
-	ePx, ePy := Curve.ScalarMult(Px, Py, IntToByte(e))
-	ePy.Sub(Curve.P, ePy)
-	Rx, Ry := Curve.Add(sGx, sGy, ePx, ePy)
 
-	if (Rx.Sign() == 0 && Ry.Sign() == 0) || big.Jacobi(Ry, Curve.P) != 1 || Rx.Cmp(r) != 0 {
-		return false, errors.New("signature verification failed")
+	e := challenge(P.x.big(), P.y.big(), IntToByte(r))
+	Rx, Ry, ok := verifyPoint(s, e, &P)
+	if !ok || !Ry.isSquare() || Rx.big().Cmp(r) != 0 {
+		return false, ErrVerificationFailed
 	}
 	return true, nil
 }
 
 //MultiVerify
-func MultiVerify(publicKey [][33]byte, message []byte, signature [64]byte) (bool, error) {
-	pubKey := aggregationPubKey(publicKey)
-	return Verify(pubKey, message, signature)
+func MultiVerify(publicKey [][33]byte, message []byte, signature [64]byte, opts ...Option) (bool, error) {
+	ctx, err := NewKeyAggContext(publicKey, opts...)
+	if err != nil {
+		return false, err
+	}
+	return ctx.MultiVerify(message, signature)
 }
 
 //VerifySignInput 验证签名的中间过程
@@ -126,47 +141,42 @@ func MultiVerify(publicKey [][33]byte, message []byte, signature [64]byte) (bool
 //publicKeys	所有参与签名的公钥
 //message		签名消息
 //signInput		签名中间结果
-func VerifySignInput(publicKeysSigned []*PublicKey, publicKeys []*PublicKey, message []byte, signInput [64]byte) (bool, error) {
-	pub := aggregationPublicKey(publicKeys)
-	Px, Py := Unmarshal(Curve, pub.P[:])
-	Rx, Ry := Unmarshal(Curve, pub.R[:])
-
-	pubSigned := aggregationPublicKey(publicKeysSigned)
-	pubSignedPx, pubSignedPy := Unmarshal(Curve, pubSigned.P[:])
-	pubSignedRx, pubSignedRy := Unmarshal(Curve, pubSigned.R[:])
-
-	r := new(big.Int).SetBytes(signInput[:32])
-	if r.Cmp(Curve.P) >= 0 {
-		return false, errors.New("r is larger than or equal to field size")
+//opts			可选配置，必须和签名时一致
+func VerifySignInput(publicKeysSigned []*PublicKey, publicKeys []*PublicKey, message []byte, signInput [64]byte, opts ...Option) (bool, error) {
+	ctx, nonces, err := newContext(publicKeys, opts)
+	if err != nil {
+		return false, err
 	}
-	s := new(big.Int).SetBytes(signInput[32:])
-	if s.Cmp(Curve.N) >= 0 {
-		return false, errors.New("s is larger than or equal to curve order")
+	signed, err := signedIndices(publicKeysSigned, publicKeys)
+	if err != nil {
+		return false, err
 	}
+	return ctx.VerifySignInput(signed, nonces, message, signInput)
+}
 
-	rX := IntToByte(Rx)
-	e := getE(Px, Py, rX, message)
-	sGx, sGy := Curve.ScalarBaseMult(IntToByte(s))
-	// e.Sub(Curve.N, e)
-	ePx, ePy := Curve.ScalarMult(pubSignedPx, pubSignedPy, IntToByte(e))
-	ePy.Sub(Curve.P, ePy)
-	Rx1, Ry1 := Curve.Add(sGx, sGy, ePx, ePy)
-	if Rx1.Sign() == 0 && Ry1.Sign() == 0 {
-		return false, errors.New("signature verification failed : Rx1, Rx1 are zero")
-	}
-	if pubSignedRx.Cmp(r) != 0 {
-		return false, errors.New("signature verification failed : pubSignedRx is not equal r")
-	}
-	if Rx1.Cmp(r) != 0 {
-		return false, errors.New("signature verification failed : Rx1 is not equal r")
-	}
-	// 所有的k都根据Ry是否jacobi做过调整, 因此通过s计算出的Ry1也是做过调整的。
-	// big.Jacobi(Ry, Curve.P) != 1 成立是，Ry1 和 pubSignedRy 是反的。
-	if big.Jacobi(Ry, Curve.P) != 1 {
-		Ry1 = new(big.Int).Sub(Curve.P, Ry1)
-	}
-	if Ry1.Cmp(pubSignedRy) != 0 {
-		return false, errors.New("signature verification failed : Ry1 is not equal pubSignedRy")
+// signedIndices publicKeysSigned 中每个公钥在 publicKeys 中的序号，(P, R) 都相同才算同一个参与者
+func signedIndices(publicKeysSigned []*PublicKey, publicKeys []*PublicKey) ([]int, error) {
+	if len(publicKeysSigned) == 0 {
+		return nil, fmt.Errorf("%w: empty publicKeysSigned", ErrInvalidLength)
 	}
-	return true, nil
-}
\ No newline at end of file
+	used := make([]bool, len(publicKeys))
+	var signed []int
+	for _, publicKey := range publicKeysSigned {
+		if publicKey == nil {
+			return nil, fmt.Errorf("%w: nil public key", ErrInvalidPoint)
+		}
+		index := -1
+		for i := range publicKeys {
+			if !used[i] && *publicKeys[i] == *publicKey {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, ErrKeyNotInSet
+		}
+		used[index] = true
+		signed = append(signed, index)
+	}
+	return signed, nil
+}
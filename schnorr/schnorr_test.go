@@ -82,18 +82,17 @@ func TestAggregateSignatures(t *testing.T) {
 		d, _ := hex.DecodeString(privkD)
 		var d32 [32]byte
 		copy(d32[:], d)
-		k0 := GetPrivateK0(d32, message)
+		k0, R33, err := GenNonce(d32, message, nil)
+		if err != nil {
+			panic(err)
+		}
 
 		Px, Py := Curve.ScalarBaseMult(d[:])
 		PubX, PubY = Curve.Add(PubX, PubY, Px, Py)
-		Rx, Ry := Curve.ScalarBaseMult(k0[:])
 
 		P := Marshal(Curve, Px, Py)
-		R := Marshal(Curve, Rx, Ry)
 		var P33 [33]byte
-		var R33 [33]byte
 		copy(P33[:], P)
-		copy(R33[:], R)
 
 		privateKeys = append(privateKeys, &PrivateKey{D:d32, K0:k0})
 		publicKeys = append(publicKeys, &PublicKey{P:P33, R:R33})
@@ -155,4 +154,105 @@ func decodePrivateKey(d string, t *testing.T) *big.Int {
 		t.Fatalf("Unexpected error from new(big.Int).SetString(%s, 16)", d)
 	}
 	return privKey
-}
\ No newline at end of file
+}
+
+func TestAggregateSignaturesHardened(t *testing.T) {
+	message, _ := hex.DecodeString("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+
+	var privateKeys []*PrivateKey
+	var publicKeys []*PublicKey
+	var pubKeys [][33]byte
+	for i := 0; i < 4; i++ {
+		d, P := GenKey()
+		k0, R, err := GenNonce(d, message, nil)
+		if err != nil {
+			panic(err)
+		}
+		privateKeys = append(privateKeys, &PrivateKey{D: d, K0: k0})
+		publicKeys = append(publicKeys, &PublicKey{P: P, R: R})
+		pubKeys = append(pubKeys, P)
+	}
+
+	opt := WithAggregation(AggregationHardened)
+	var sign [64]byte
+	var err error
+	for i, privateKey := range privateKeys {
+		sign, err = AppendSignature(sign, message, privateKey, publicKeys, i, opt)
+		if err != nil {
+			panic(err)
+		}
+		ret, err := VerifySignInput(publicKeys[:i+1], publicKeys, message, sign, opt)
+		if err != nil || !ret {
+			panic("验证中间签名失败")
+		}
+	}
+
+	ret, err := MultiVerify(pubKeys, message, sign, opt)
+	if err != nil {
+		panic(err)
+	}
+	if !ret {
+		panic("验证失败")
+	}
+	if ret, _ := MultiVerify(pubKeys, message, sign); ret {
+		panic("聚合方式不同，验证应该失败")
+	}
+}
+
+func TestRogueKey(t *testing.T) {
+	message := []byte("test msg")
+	_, honest := GenKey()
+
+	// 攻击者选择 P' = X - P, 直接相加得到的聚合公钥就是 X
+	x, X := GenKey()
+	Xx, Xy := Unmarshal(Curve, X[:])
+	Hx, Hy := Unmarshal(Curve, honest[:])
+	rogueX, rogueY := Curve.Add(Xx, Xy, Hx, new(big.Int).Sub(Curve.P, Hy))
+	var rogue [33]byte
+	copy(rogue[:], Marshal(Curve, rogueX, rogueY))
+
+	k0, R, err := GenNonce(x, message, nil)
+	if err != nil {
+		panic(err)
+	}
+	Rx, _, s, err := Sign(message, &PrivateKey{D: x, K0: k0}, []*PublicKey{{P: X, R: R}})
+	if err != nil {
+		panic(err)
+	}
+	var sign [64]byte
+	copy(sign[:32], IntToByte(Rx))
+	copy(sign[32:], IntToByte(s))
+
+	keys := [][33]byte{honest, rogue}
+	if ret, _ := MultiVerify(keys, message, sign); !ret {
+		panic("直接相加时攻击应该成功")
+	}
+	if ret, _ := MultiVerify(keys, message, sign, WithAggregation(AggregationHardened)); ret {
+		panic("带系数聚合时攻击应该失败")
+	}
+}
+
+func TestPossession(t *testing.T) {
+	d, P := GenKey()
+	proof, err := ProvePossession(d)
+	if err != nil {
+		panic(err)
+	}
+	if ret, err := VerifyPossession(P, proof); err != nil || !ret {
+		panic("持有证明验证失败")
+	}
+
+	// 换一个公钥不能通过
+	_, other := GenKey()
+	if ret, _ := VerifyPossession(other, proof); ret {
+		panic("持有证明不应该对其他公钥有效")
+	}
+
+	// 持有证明不能当作签名使用
+	if ret, _ := Verify(P, []byte(possessionTag), proof); ret {
+		panic("持有证明不应该是合法的签名")
+	}
+	if ret, _ := Verify(P, nil, proof); ret {
+		panic("持有证明不应该是合法的签名")
+	}
+}
@@ -0,0 +1,109 @@
+package schnorr
+
+import (
+	"fmt"
+)
+
+const subgroupTag = "schnorr-go/subgroup"
+
+var errPublicKeysBitmap = fmt.Errorf("%w: publicKeys do not match bitmap", ErrInvalidBitmap)
+
+// subgroup 登记的全部公钥和本次参与签名的成员
+type subgroup struct {
+	keySet [][33]byte
+	bitmap []byte
+}
+
+// WithSubgroup 由登记的公钥集合 keySet 中的一部分成员签名，bitmap 标记参与签名的成员
+// 签名时 publicKeys 必须是 keySet 中 bitmap 选中的公钥，按 keySet 中的顺序排列
+// 聚合系数由整个 keySet 计算(相当于 AggregationHardened)，消息中绑定 keySet 和 bitmap，
+// 签名只对这一组成员有效，未参与的成员不需要做任何事
+func WithSubgroup(keySet [][33]byte, bitmap []byte) Option {
+	return func(o *options) {
+		o.subgroup = &subgroup{
+			keySet: append([][33]byte{}, keySet...),
+			bitmap: append([]byte{}, bitmap...),
+		}
+	}
+}
+
+// NewBitmap 标记 keySet 中第 indices 个成员，n 是 keySet 的大小
+func NewBitmap(n int, indices ...int) ([]byte, error) {
+	bitmap := make([]byte, (n+7)/8)
+	for _, i := range indices {
+		if i < 0 || i >= n {
+			return nil, ErrInvalidIndex
+		}
+		bitmap[i/8] |= 1 << uint(i%8)
+	}
+	return bitmap, nil
+}
+
+// SubgroupSigners bitmap 选中的成员公钥，审计时用于查看谁参与了签名
+func SubgroupSigners(keySet [][33]byte, bitmap []byte) ([][33]byte, error) {
+	if len(bitmap) != (len(keySet)+7)/8 {
+		return nil, fmt.Errorf("%w: invalid bitmap length", ErrInvalidBitmap)
+	}
+	var signers [][33]byte
+	for i := 0; i < 8*len(bitmap); i++ {
+		if bitmap[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+		if i >= len(keySet) {
+			return nil, ErrInvalidBitmap
+		}
+		signers = append(signers, keySet[i])
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("%w: empty bitmap", ErrInvalidBitmap)
+	}
+	return signers, nil
+}
+
+// VerifySubgroup 验证签名确实由 keySet 中 bitmap 选中的成员共同生成
+func VerifySubgroup(keySet [][33]byte, bitmap []byte, message []byte, signature [64]byte) (bool, error) {
+	signers, err := SubgroupSigners(keySet, bitmap)
+	if err != nil {
+		return false, err
+	}
+	return MultiVerify(signers, message, signature, WithSubgroup(keySet, bitmap))
+}
+
+// MarshalSubgroupSignature 带成员标记的签名 bitmap||signature
+func MarshalSubgroupSignature(bitmap []byte, signature [64]byte) []byte {
+	return append(append([]byte{}, bitmap...), signature[:]...)
+}
+
+// ParseSubgroupSignature 解析 MarshalSubgroupSignature 的结果，n 是 keySet 的大小
+func ParseSubgroupSignature(n int, data []byte) (bitmap []byte, signature [64]byte, err error) {
+	size := (n + 7) / 8
+	if n <= 0 || len(data) != size+64 {
+		return nil, signature, fmt.Errorf("%w: invalid subgroup signature length", ErrInvalidLength)
+	}
+	bitmap = append([]byte{}, data[:size]...)
+	copy(signature[:], data[size:])
+	return bitmap, signature, nil
+}
+
+// checkKeys 检查 publicKeys 是否就是 bitmap 选中的成员
+func (g *subgroup) checkKeys(publicKeys [][33]byte) error {
+	signers, err := SubgroupSigners(g.keySet, g.bitmap)
+	if err != nil {
+		return err
+	}
+	if len(signers) != len(publicKeys) {
+		return errPublicKeysBitmap
+	}
+	for i := range signers {
+		if signers[i] != publicKeys[i] {
+			return errPublicKeysBitmap
+		}
+	}
+	return nil
+}
+
+// message 签名的实际消息 TaggedHash("schnorr-go/subgroup", L||bitmap||m)，L 是 keySet 的哈希
+func (g *subgroup) message(keysHash [32]byte, message []byte) []byte {
+	h := TaggedHash(subgroupTag, keysHash[:], g.bitmap, message)
+	return h[:]
+}
@@ -0,0 +1,124 @@
+package schnorr
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+)
+
+// dudect 风格的计时测试
+// 两类秘密输入(固定值和随机值)随机交替，分别测量运行时间，去掉最慢的一部分(中断、调度造成的噪声)之后
+// 用 Welch t 检验比较两组的均值，|t| 超过阈值说明运行时间和秘密有关
+const timingThreshold = 10
+
+func timingT(t *testing.T, n int, fixed [32]byte, f func(secret *[32]byte)) float64 {
+	inputs := make([][32]byte, n)
+	classes := make([]byte, n)
+	if _, err := rand.Read(classes); err != nil {
+		t.Fatal(err)
+	}
+	for i := range inputs {
+		classes[i] &= 1
+		if classes[i] == 0 {
+			inputs[i] = fixed
+		} else if _, err := rand.Read(inputs[i][:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 预热
+	for i := 0; i < n/10; i++ {
+		f(&inputs[i])
+	}
+	durations := make([]float64, n)
+	for i := range inputs {
+		start := time.Now()
+		f(&inputs[i])
+		durations[i] = float64(time.Since(start))
+	}
+
+	sorted := append([]float64{}, durations...)
+	sort.Float64s(sorted)
+	crop := sorted[n*9/10]
+	var count, mean, m2 [2]float64
+	for i, d := range durations {
+		if d > crop {
+			continue
+		}
+		c := classes[i]
+		count[c]++
+		delta := d - mean[c]
+		mean[c] += delta / count[c]
+		m2[c] += delta * (d - mean[c])
+	}
+	v0 := m2[0] / (count[0] - 1) / count[0]
+	v1 := m2[1] / (count[1] - 1) / count[1]
+	return (mean[0] - mean[1]) / math.Sqrt(v0+v1)
+}
+
+func TestConstantTimeBaseMult(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	var one [32]byte
+	one[31] = 1
+	tValue := timingT(t, 4000, one, func(k *[32]byte) {
+		ScalarBaseMult(*k)
+	})
+	if math.Abs(tValue) > timingThreshold {
+		t.Fatalf("ScalarBaseMult timing depends on the scalar, t = %.2f", tValue)
+	}
+}
+
+func TestConstantTimeResponse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	e, _ := rand.Int(rand.Reader, Curve.N)
+	var one [32]byte
+	one[31] = 1
+	tValue := timingT(t, 20000, one, func(secret *[32]byte) {
+		response(secret, secret, secret[0]&1 == 1, e)
+	})
+	if math.Abs(tValue) > timingThreshold {
+		t.Fatalf("response timing depends on the secret, t = %.2f", tValue)
+	}
+}
+
+func TestConstantTimeScalar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	other := new(scalar).setBig(big.NewInt(12345))
+	var zero [32]byte
+	tValue := timingT(t, 20000, zero, func(secret *[32]byte) {
+		var s scalar
+		s.setBytes(secret)
+		s.mul(&s, other)
+		s.add(&s, other)
+		s.condNeg(s.isZero())
+		s.inv(&s)
+	})
+	if math.Abs(tValue) > timingThreshold {
+		t.Fatalf("scalar arithmetic timing depends on the secret, t = %.2f", tValue)
+	}
+}
+
+func TestConstantTimePointMul(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	P := new(Point).BaseMul(new(Scalar).SetBig(big.NewInt(7)))
+	var one [32]byte
+	one[31] = 1
+	tValue := timingT(t, 2000, one, func(secret *[32]byte) {
+		var k Scalar
+		k.s.setBytes(secret)
+		new(Point).Mul(&k, P)
+	})
+	if math.Abs(tValue) > timingThreshold {
+		t.Fatalf("Point.Mul timing depends on the scalar, t = %.2f", tValue)
+	}
+}
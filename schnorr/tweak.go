@@ -0,0 +1,100 @@
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const tapTweakTag = "TapTweak"
+
+// tweak WithTweak 提交的数据
+type tweak struct {
+	data []byte
+}
+
+// WithTweak 签名对应的公钥为聚合公钥 P 调整后的 Q = TweakPublicKey(P, data)
+// 签名结果用 Verify(Q, ...) 验证，P 可以作为 Taproot 内部公钥，ToXOnly(Q) 为输出公钥
+// 只有 publicKeys 中的第一个参与者在部分签名中加上 e*t，其他参与者照常签名
+func WithTweak(data []byte) Option {
+	return func(o *options) {
+		o.tweak = &tweak{data: append([]byte{}, data...)}
+	}
+}
+
+// WithPlainTweak 签名对应的公钥为聚合公钥加上 t*G，Q = P + t*G，不要求 y 为偶数
+// 用于 BIP-328 风格的非强化派生，t 为 hdkey 派生路径上所有 IL 之和
+// 和 WithTweak 同时使用时先加 t*G，再对结果做 Taproot 调整，InternalKey 为 P + t*G
+// 和 WithTweak 一样只有 publicKeys 中的第一个参与者在部分签名中加上 e*t
+func WithPlainTweak(t [32]byte) Option {
+	return func(o *options) {
+		o.plainTweak = &t
+	}
+}
+
+// tapTweak t = TaggedHash("TapTweak", Px||data)，t >= N 时返回错误(BIP-341)
+func tapTweak(Px []byte, data []byte) (*big.Int, error) {
+	h := TaggedHash(tapTweakTag, Px, data)
+	t := new(big.Int).SetBytes(h[:])
+	if t.Cmp(Curve.N) >= 0 {
+		return nil, fmt.Errorf("%w: tweak is larger than or equal to curve order", ErrScalarOutOfRange)
+	}
+	return t, nil
+}
+
+// tweakPoint Q = P' + t*G，P' 是 x 坐标和 P 相同、y 为偶数的点
+// negate 表示 P 的 y 为奇数，P' = -P
+func tweakPoint(P *jacobianPoint, data []byte) (Q jacobianPoint, t *big.Int, negate bool, err error) {
+	x, y := P.affine()
+	xb := x.bytes()
+	t, err = tapTweak(xb[:], data)
+	if err != nil {
+		return Q, nil, false, err
+	}
+	negate = y.isOdd()
+	var even jacobianPoint
+	even.setAffine(&x, &y)
+	if negate {
+		even.neg(&even)
+	}
+	Q = doubleScalarMult(t, One, &even)
+	if Q.isInfinity() {
+		return Q, nil, false, fmt.Errorf("%w: tweaked public key is infinity", ErrInvalidPoint)
+	}
+	return Q, t, negate, nil
+}
+
+// TweakPublicKey 把 data 提交到公钥中，Q = P' + t*G，t = TaggedHash("TapTweak", Px||data)
+// P' 是 x 坐标和 P 相同、y 为偶数的点，即 Taproot 内部公钥，data 通常是脚本树的 merkle 根，为空时只提交 P 本身
+// ToXOnly(Q) 就是 BIP-341 的输出公钥
+func TweakPublicKey(publicKey [33]byte, data []byte) (Q [33]byte, err error) {
+	P, ok := decompress(publicKey[:])
+	if !ok {
+		return Q, fmt.Errorf("%w: invalid public key", ErrInvalidPoint)
+	}
+	tweaked, _, _, err := tweakPoint(&P, data)
+	if err != nil {
+		return Q, err
+	}
+	return compress(&tweaked), nil
+}
+
+// TweakPrivateKey TweakPublicKey 对应的私钥 q = d' + t，P = d*G 的 y 为奇数时 d' = N-d
+// q*G 等于 TweakPublicKey(P, data)，q 可以直接用于 Sign 和 SignBIP340
+func TweakPrivateKey(d [32]byte, data []byte) (q [32]byte, err error) {
+	if err := checkScalar(d); err != nil {
+		return q, fmt.Errorf("%w: invalid private key", err)
+	}
+	var x scalar
+	x.setBytes(&d)
+	Px, Py := baseMult(&x)
+	x.condNeg(uint64(Py.Bit(0)))
+	t, err := tapTweak(IntToByte(Px), data)
+	if err != nil {
+		return q, err
+	}
+	x.add(&x, new(scalar).setBig(t))
+	if x.isZero() == 1 {
+		return q, fmt.Errorf("%w: tweaked private key is zero", ErrScalarOutOfRange)
+	}
+	return x.bytes(), nil
+}
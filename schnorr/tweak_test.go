@@ -0,0 +1,211 @@
+package schnorr
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// BIP-341 wallet-test-vectors.json 中 scriptPubKey 的前两个
+func TestTweakPublicKeyBIP341(t *testing.T) {
+	vectors := []struct {
+		internal, merkleRoot, tweaked string
+	}{
+		{"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d", "",
+			"53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343"},
+		{"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27", "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			"147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3"},
+	}
+	for _, v := range vectors {
+		var internal XOnlyPublicKey
+		b, _ := hex.DecodeString(v.internal)
+		copy(internal[:], b)
+		merkleRoot, _ := hex.DecodeString(v.merkleRoot)
+		for _, P := range [][33]byte{internal.Compressed(), oddKey(internal)} {
+			Q, err := TweakPublicKey(P, merkleRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(Q[1:]) != v.tweaked {
+				t.Fatalf("TweakPublicKey(%s) = %x", v.internal, Q[1:])
+			}
+		}
+	}
+}
+
+// oddKey x 坐标相同、y 为奇数的公钥，调整结果应该相同
+func oddKey(pk XOnlyPublicKey) [33]byte {
+	P := pk.Compressed()
+	P[0] = 3
+	return P
+}
+
+func TestTweakPrivateKey(t *testing.T) {
+	message := []byte("test msg")
+	data := []byte("script tree")
+	for i := 0; i < 10; i++ {
+		d, P := GenKey()
+		q, err := TweakPrivateKey(d, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Q, err := TweakPublicKey(P, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Qx, Qy := ScalarBaseMult(q)
+		if string(Marshal(Curve, Qx, Qy)) != string(Q[:]) {
+			t.Fatal("q*G is not equal to Q")
+		}
+		// 调整后的私钥可以直接签 BIP-340
+		xonly, err := ToXOnly(Q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := SignBIP340(q, message, [32]byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret, err := VerifyBIP340(xonly, message, signature); err != nil || !ret {
+			t.Fatal("BIP-340 signature with tweaked key failed", err)
+		}
+	}
+	if _, err := TweakPrivateKey([32]byte{}, data); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Fatal("zero private key should fail")
+	}
+	if _, err := TweakPublicKey([33]byte{2}, data); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatal("invalid public key should fail")
+	}
+}
+
+func TestMultiSignTweak(t *testing.T) {
+	message := []byte("test msg")
+	data := []byte("script tree")
+	// 多跑几次，覆盖聚合公钥 y 为奇数和偶数两种情况
+	for round := 0; round < 8; round++ {
+		var privateKeys []*PrivateKey
+		var publicKeys []*PublicKey
+		var keys [][33]byte
+		for i := 0; i < 3; i++ {
+			d, P := GenKey()
+			k0, R, err := GenNonce(d, message, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			privateKeys = append(privateKeys, &PrivateKey{D: d, K0: k0})
+			publicKeys = append(publicKeys, &PublicKey{P: P, R: R})
+			keys = append(keys, P)
+		}
+		opts := []Option{WithAggregation(AggregationHardened), WithTweak(data)}
+		ctx, err := NewKeyAggContext(keys, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internal, err := NewKeyAggContext(keys, WithAggregation(AggregationHardened))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Q, err := TweakPublicKey(internal.PublicKey(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ctx.InternalKey() != internal.PublicKey() || ctx.PublicKey() != Q {
+			t.Fatal("tweaked aggregate key mismatch")
+		}
+
+		// 按 1, 2, 0 的顺序签名，第一个参与者加上 e*t
+		var signature [64]byte
+		var signed []int
+		for _, i := range []int{1, 2, 0} {
+			if ret, err := VerifySignInputSigned(signed, publicKeys, message, signature, opts...); err != nil || !ret {
+				t.Fatal("sign input verification failed", err)
+			}
+			signature, err = AppendSignatureSigned(signature, message, privateKeys[i], publicKeys, signed, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed = append(signed, i)
+		}
+		if ret, err := Verify(Q, message, signature); err != nil || !ret {
+			t.Fatal("signature is not valid under Q", err)
+		}
+		if ret, err := MultiVerify(keys, message, signature, opts...); err != nil || !ret {
+			t.Fatal("MultiVerify with tweak failed", err)
+		}
+		if ret, _ := MultiVerify(keys, message, signature, WithAggregation(AggregationHardened)); ret {
+			t.Fatal("signature should not be valid under the internal key")
+		}
+	}
+}
+
+func TestMultiSignPlainTweak(t *testing.T) {
+	message := []byte("test msg")
+	for round := 0; round < 8; round++ {
+		var privateKeys []*PrivateKey
+		var publicKeys []*PublicKey
+		var keys [][33]byte
+		for i := 0; i < 3; i++ {
+			d, P := GenKey()
+			k0, R, err := GenNonce(d, message, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			privateKeys = append(privateKeys, &PrivateKey{D: d, K0: k0})
+			publicKeys = append(publicKeys, &PublicKey{P: P, R: R})
+			keys = append(keys, P)
+		}
+		tweak, err := RandomScalar()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 先加 t*G，再做 Taproot 调整
+		opts := []Option{WithAggregation(AggregationHardened), WithPlainTweak(tweak.Bytes()), WithTweak(nil)}
+		ctx, err := NewKeyAggContext(keys, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		aggregate := ctx.AggregateKey()
+		P, err := new(Point).SetBytes(aggregate[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		P.Add(P, new(Point).BaseMul(tweak))
+		Q, err := TweakPublicKey(P.Bytes(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ctx.InternalKey() != P.Bytes() || ctx.PublicKey() != Q {
+			t.Fatal("tweaked aggregate key mismatch")
+		}
+
+		var signature [64]byte
+		var signed []int
+		for _, i := range []int{2, 0, 1} {
+			if ret, err := VerifySignInputSigned(signed, publicKeys, message, signature, opts...); err != nil || !ret {
+				t.Fatal("sign input verification failed", err)
+			}
+			signature, err = AppendSignatureSigned(signature, message, privateKeys[i], publicKeys, signed, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed = append(signed, i)
+		}
+		if ret, err := Verify(Q, message, signature); err != nil || !ret {
+			t.Fatal("signature is not valid under Q", err)
+		}
+	}
+
+	d, P := GenKey()
+	var N [32]byte
+	copy(N[:], Curve.N.Bytes())
+	if _, err := NewKeyAggContext([][33]byte{P}, WithPlainTweak(N)); !errors.Is(err, ErrScalarOutOfRange) {
+		t.Fatal("tweak >= N should fail")
+	}
+	// 加上 -d*G 后为无穷远点
+	var negD Scalar
+	negD.SetBytes(d)
+	negD.Neg(&negD)
+	if _, err := NewKeyAggContext([][33]byte{P}, WithPlainTweak(negD.Bytes())); !errors.Is(err, ErrInvalidPoint) {
+		t.Fatal("infinity should fail")
+	}
+}